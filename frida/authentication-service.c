@@ -1,41 +1,51 @@
 #include "authentication-service.h"
 
+#include <stdlib.h>
+
 void init_frida (void) __attribute__ ((constructor));
 
 void init_frida(void) {
   frida_init ();
 }
 
-extern void * authenticate(void*,char*);
+extern void goAuthenticate(uintptr_t,char*,GCancellable*,GTask*);
+extern void goAuthenticationCancelled(uintptr_t);
+extern void goAuthenticationReleased(uintptr_t);
 
 struct _GoAuthenticationService {
   GObject parent;
-  void * callback;
-  GThreadPool * pool;
+  uintptr_t handle;
 };
 
-GoAuthenticationService * frida_go_authentication_service_new (void * callback);
 static void frida_go_authentication_service_iface_init (gpointer g_iface, gpointer iface_data);
 static void frida_go_authentication_service_dispose (GObject * object);
 static void frida_go_authentication_service_authenticate (FridaAuthenticationService * service, const gchar * token,
     GCancellable * cancellable, GAsyncReadyCallback callback, gpointer user_data);
 static gchar * frida_go_authentication_service_authenticate_finish (FridaAuthenticationService * service, GAsyncResult * result,
     GError ** error);
-static void frida_go_authentication_service_do_authenticate (GTask * task, GoAuthenticationService * self);
+static void frida_go_authentication_on_cancelled (GCancellable * cancellable, gpointer user_data);
 
 G_DEFINE_TYPE_EXTENDED (GoAuthenticationService, frida_go_authentication_service, G_TYPE_OBJECT, 0,
     G_IMPLEMENT_INTERFACE (FRIDA_TYPE_AUTHENTICATION_SERVICE, frida_go_authentication_service_iface_init))
 
 
-GoAuthenticationService * frida_go_authentication_service_new (void * callback) {
+GoAuthenticationService * frida_go_authentication_service_new (uintptr_t handle) {
   GoAuthenticationService * service = NULL;
 
   service = g_object_new (FRIDA_TYPE_GO_AUTHENTICATION_SERVICE, NULL);
-  service->callback = callback;
+  service->handle = handle;
 
   return service;
 }
 
+gulong frida_go_authentication_connect_cancellable (GCancellable * cancellable, uintptr_t handle) {
+  return g_cancellable_connect (cancellable, G_CALLBACK (frida_go_authentication_on_cancelled), (gpointer) handle, NULL);
+}
+
+static void frida_go_authentication_on_cancelled (GCancellable * cancellable, gpointer user_data) {
+  goAuthenticationCancelled ((uintptr_t) user_data);
+}
+
 static void frida_go_authentication_service_iface_init (gpointer g_iface, gpointer iface_data){
   FridaAuthenticationServiceIface * iface = g_iface;
 
@@ -53,14 +63,10 @@ frida_go_authentication_service_class_init (GoAuthenticationServiceClass * klass
 
 static void frida_go_authentication_service_dispose (GObject * object) {
   GoAuthenticationService * self = FRIDA_GO_AUTHENTICATION_SERVICE(object);
-  
-  if (self->pool != NULL) {
-    g_thread_pool_free (self->pool, FALSE, FALSE);
-    self->pool = NULL;
-  }
 
-  if (self->callback != NULL) {
-    self->callback = NULL;
+  if (self->handle != 0) {
+    goAuthenticationReleased (self->handle);
+    self->handle = 0;
   }
 
   G_OBJECT_CLASS (frida_go_authentication_service_parent_class)->dispose (object);
@@ -69,7 +75,6 @@ static void frida_go_authentication_service_dispose (GObject * object) {
 static void
 frida_go_authentication_service_init (GoAuthenticationService * self)
 {
-  self->pool = g_thread_pool_new ((GFunc) frida_go_authentication_service_do_authenticate, self, 1, FALSE, NULL);
 }
 
 static void frida_go_authentication_service_authenticate (FridaAuthenticationService * service, const gchar * token,
@@ -81,9 +86,20 @@ GCancellable * cancellable, GAsyncReadyCallback callback, gpointer user_data)
   self = FRIDA_GO_AUTHENTICATION_SERVICE (service);
 
   task = g_task_new (self, cancellable, callback, user_data);
-  g_task_set_task_data (task, g_strdup (token), g_free);
 
-  g_thread_pool_push (self->pool, task, NULL);
+  /* goAuthenticate returns right away, the authenticator runs on its own goroutine
+   * which owns the reference of the task and completes it with frida_go_authentication_return */
+  goAuthenticate (self->handle, (char *) token, cancellable, task);
+}
+
+void frida_go_authentication_return (GTask * task, const char * session_info, const char * error_message) {
+  if (session_info != NULL) {
+    g_task_return_pointer (task, g_strdup (session_info), g_free);
+  } else {
+    g_task_return_new_error (task, FRIDA_ERROR, FRIDA_ERROR_INVALID_ARGUMENT, "%s",
+        (error_message != NULL) ? error_message : "Authentication failed");
+  }
+  g_object_unref (task);
 }
 
 static gchar *
@@ -91,31 +107,3 @@ frida_go_authentication_service_authenticate_finish (FridaAuthenticationService
 {
   return g_task_propagate_pointer (G_TASK (result), error);
 }
-
-static void
-frida_go_authentication_service_do_authenticate (GTask * task, GoAuthenticationService * self)
-{
-    const gchar * token;
-    const gchar * session_info = NULL;
-    gchar * message;
-    void * result = NULL;
-
-    token = g_task_get_task_data (task);
-
-    result = authenticate(self->callback, (char*)token);
-
-    if (result == NULL) {
-        message = g_strdup ("Internal error");
-    }
-
-    session_info = (char*)result;
-        
-    if (session_info != NULL) {
-        g_task_return_pointer (task, session_info, g_free);
-    } else {
-        g_task_return_new_error (task, FRIDA_ERROR, FRIDA_ERROR_INVALID_ARGUMENT, "%s", message);
-    }
-
-    g_free (message);
-    g_object_unref (task);
-}
\ No newline at end of file
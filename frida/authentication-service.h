@@ -2,10 +2,13 @@
 #define __AUTHENTICATION_SERVICE_H__
 
 #include <frida-core.h>
+#include <stdint.h>
 
 #define FRIDA_TYPE_GO_AUTHENTICATION_SERVICE (frida_go_authentication_service_get_type ())
 G_DECLARE_FINAL_TYPE (GoAuthenticationService, frida_go_authentication_service, FRIDA, GO_AUTHENTICATION_SERVICE, GObject)
 
-GoAuthenticationService * frida_go_authentication_service_new (void * callback);
+GoAuthenticationService * frida_go_authentication_service_new (uintptr_t handle);
+gulong frida_go_authentication_connect_cancellable (GCancellable * cancellable, uintptr_t handle);
+void frida_go_authentication_return (GTask * task, const char * session_info, const char * error_message);
 
 #endif 
\ No newline at end of file
@@ -0,0 +1,166 @@
+package frida
+
+//#include "authentication-service.h"
+import "C"
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"runtime/cgo"
+	"unsafe"
+)
+
+// Authenticator authenticates the clients connecting to the endpoint.
+// Authenticate returns the session info which is marshalled into JSON and passed to the portal
+// in the "authenticated" signal; nil is sent as empty object, strings and []byte holding valid JSON are
+// sent as is. Returned error rejects the client and its message is sent to the client.
+// ctx is cancelled once the client disconnects or the endpoint is stopped; from then on the client
+// is rejected whatever Authenticate returns.
+//
+// Authenticate runs on its own goroutine for every client so it can block without holding up frida,
+// and it can be called concurrently. There is no timeout, the client waits until Authenticate returns,
+// so the authenticators calling out to other services should bound the call with context.WithTimeout.
+type Authenticator interface {
+	Authenticate(ctx context.Context, token string) (sessionInfo any, err error)
+}
+
+// AuthenticatorFunc adapts the function into the Authenticator.
+type AuthenticatorFunc func(ctx context.Context, token string) (any, error)
+
+// Authenticate calls f.
+func (f AuthenticatorFunc) Authenticate(ctx context.Context, token string) (any, error) {
+	return f(ctx, token)
+}
+
+// StaticTokenAuthenticator accepts only the token provided, compared in the constant time.
+func StaticTokenAuthenticator(token string) Authenticator {
+	return AuthenticatorFunc(func(ctx context.Context, t string) (any, error) {
+		if subtle.ConstantTimeCompare([]byte(t), []byte(token)) != 1 {
+			return nil, ErrInvalidToken
+		}
+		return nil, nil
+	})
+}
+
+// ChainAuthenticators tries the authenticators in order and returns the result of the first one
+// accepting the token. If all of them reject the token, the last error is returned.
+func ChainAuthenticators(authenticators ...Authenticator) Authenticator {
+	return AuthenticatorFunc(func(ctx context.Context, token string) (any, error) {
+		err := ErrInvalidToken
+		for _, auth := range authenticators {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return nil, ctxErr
+			}
+
+			var sessionInfo any
+			sessionInfo, err = auth.Authenticate(ctx, token)
+			if err == nil {
+				return sessionInfo, nil
+			}
+		}
+		return nil, err
+	})
+}
+
+func sessionInfoToJSON(sessionInfo any) (string, error) {
+	switch v := sessionInfo.(type) {
+	case nil:
+		return "{}", nil
+	case string:
+		if json.Valid([]byte(v)) {
+			return v, nil
+		}
+	case []byte:
+		if json.Valid(v) {
+			return string(v), nil
+		}
+	}
+
+	data, err := json.Marshal(sessionInfo)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func runAuthenticator(ctx context.Context, auth Authenticator, token string) (info string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("authenticator panicked: %v", r)
+		}
+	}()
+
+	sessionInfo, err := auth.Authenticate(ctx, token)
+	if err != nil {
+		return "", err
+	}
+	return sessionInfoToJSON(sessionInfo)
+}
+
+// registerAuthenticator returns the handle passed to the C side of the authentication
+// service, it is valid until releaseAuthenticator is called.
+func registerAuthenticator(auth Authenticator) uintptr {
+	return uintptr(cgo.NewHandle(auth))
+}
+
+func lookupAuthenticator(handle uintptr) Authenticator {
+	return cgo.Handle(handle).Value().(Authenticator)
+}
+
+func releaseAuthenticator(handle uintptr) {
+	cgo.Handle(handle).Delete()
+}
+
+//export goAuthenticate
+func goAuthenticate(handle C.uintptr_t, token *C.char, cancellable *C.GCancellable, task *C.GTask) {
+	auth := lookupAuthenticator(uintptr(handle))
+	tkn := C.GoString(token)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var cancelHandle cgo.Handle
+	var id C.gulong
+	if cancellable != nil {
+		cancelHandle = cgo.NewHandle(cancel)
+		id = C.frida_go_authentication_connect_cancellable(cancellable, C.uintptr_t(cancelHandle))
+	}
+
+	go func() {
+		defer cancel()
+
+		info, err := runAuthenticator(ctx, auth, tkn)
+		if cancellable != nil {
+			C.g_cancellable_disconnect(cancellable, id)
+			cancelHandle.Delete()
+		}
+
+		if err != nil {
+			errMsg := C.CString(err.Error())
+			defer C.free(unsafe.Pointer(errMsg))
+			C.frida_go_authentication_return(task, nil, errMsg)
+			return
+		}
+		infoC := C.CString(info)
+		defer C.free(unsafe.Pointer(infoC))
+		C.frida_go_authentication_return(task, infoC, nil)
+	}()
+}
+
+//export goAuthenticationCancelled
+func goAuthenticationCancelled(handle C.uintptr_t) {
+	cgo.Handle(handle).Value().(context.CancelFunc)()
+}
+
+//export goAuthenticationReleased
+func goAuthenticationReleased(handle C.uintptr_t) {
+	releaseAuthenticator(uintptr(handle))
+}
+
+// newAuthenticationService creates the authentication service calling auth, the handle
+// holding auth is released once the service is disposed.
+func newAuthenticationService(auth Authenticator) *C.FridaAuthenticationService {
+	h := registerAuthenticator(auth)
+	service := C.frida_go_authentication_service_new(C.uintptr_t(h))
+	return (*C.FridaAuthenticationService)(unsafe.Pointer(service))
+}
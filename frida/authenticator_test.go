@@ -0,0 +1,152 @@
+package frida
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestAuthenticatorHandle(t *testing.T) {
+	auth := StaticTokenAuthenticator("secret")
+	h := registerAuthenticator(auth)
+
+	got := lookupAuthenticator(h)
+	if _, err := got.Authenticate(context.Background(), "secret"); err != nil {
+		t.Errorf("Authenticate() of the registered authenticator error = %v", err)
+	}
+
+	releaseAuthenticator(h)
+	defer func() {
+		if recover() == nil {
+			t.Error("lookupAuthenticator() of the released handle did not panic")
+		}
+	}()
+	lookupAuthenticator(h)
+}
+
+func TestRunAuthenticator(t *testing.T) {
+	errDenied := errors.New("denied")
+
+	tests := []struct {
+		name    string
+		auth    Authenticator
+		token   string
+		want    string
+		wantErr error
+		errText string
+	}{
+		{name: "static token", auth: StaticTokenAuthenticator("secret"), token: "secret", want: "{}"},
+		{name: "invalid token", auth: StaticTokenAuthenticator("secret"), token: "guess", wantErr: ErrInvalidToken},
+		{name: "empty token", auth: StaticTokenAuthenticator("secret"), wantErr: ErrInvalidToken},
+		{
+			name: "session info",
+			auth: AuthenticatorFunc(func(ctx context.Context, token string) (any, error) {
+				return map[string]string{"user": token}, nil
+			}),
+			token: "alice",
+			want:  `{"user":"alice"}`,
+		},
+		{
+			name: "json string",
+			auth: AuthenticatorFunc(func(ctx context.Context, token string) (any, error) {
+				return `{"user":"bob"}`, nil
+			}),
+			want: `{"user":"bob"}`,
+		},
+		{
+			name: "plain string",
+			auth: AuthenticatorFunc(func(ctx context.Context, token string) (any, error) {
+				return "bob", nil
+			}),
+			want: `"bob"`,
+		},
+		{
+			name: "json bytes",
+			auth: AuthenticatorFunc(func(ctx context.Context, token string) (any, error) {
+				return []byte(`[1,2]`), nil
+			}),
+			want: `[1,2]`,
+		},
+		{
+			name: "error",
+			auth: AuthenticatorFunc(func(ctx context.Context, token string) (any, error) {
+				return nil, errDenied
+			}),
+			wantErr: errDenied,
+		},
+		{
+			name: "unmarshallable session info",
+			auth: AuthenticatorFunc(func(ctx context.Context, token string) (any, error) {
+				return make(chan int), nil
+			}),
+			errText: "unsupported type",
+		},
+		{
+			name: "panic",
+			auth: AuthenticatorFunc(func(ctx context.Context, token string) (any, error) {
+				panic("boom")
+			}),
+			errText: "authenticator panicked: boom",
+		},
+		{
+			name: "chain",
+			auth: ChainAuthenticators(
+				StaticTokenAuthenticator("first"),
+				StaticTokenAuthenticator("second"),
+			),
+			token: "second",
+			want:  "{}",
+		},
+		{
+			name: "chain rejected",
+			auth: ChainAuthenticators(
+				StaticTokenAuthenticator("first"),
+				AuthenticatorFunc(func(ctx context.Context, token string) (any, error) {
+					return nil, errDenied
+				}),
+			),
+			token:   "third",
+			wantErr: errDenied,
+		},
+		{name: "empty chain", auth: ChainAuthenticators(), wantErr: ErrInvalidToken},
+	}
+
+	for _, tt := range tests {
+		h := registerAuthenticator(tt.auth)
+		got, err := runAuthenticator(context.Background(), lookupAuthenticator(h), tt.token)
+		releaseAuthenticator(h)
+
+		switch {
+		case tt.wantErr != nil:
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("%s: error = %v, want %v", tt.name, err, tt.wantErr)
+			}
+		case tt.errText != "":
+			if err == nil || !strings.Contains(err.Error(), tt.errText) {
+				t.Errorf("%s: error = %v, want %q", tt.name, err, tt.errText)
+			}
+		case err != nil:
+			t.Errorf("%s: error = %v", tt.name, err)
+		case got != tt.want:
+			t.Errorf("%s: session info = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestChainAuthenticatorsCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	called := false
+	auth := ChainAuthenticators(AuthenticatorFunc(func(ctx context.Context, token string) (any, error) {
+		called = true
+		return nil, nil
+	}))
+	if _, err := runAuthenticator(ctx, auth, "token"); !errors.Is(err, context.Canceled) {
+		t.Errorf("error = %v, want %v", err, context.Canceled)
+	}
+	if called {
+		t.Error("authenticator called after the context was cancelled")
+	}
+}
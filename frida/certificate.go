@@ -1,8 +1,29 @@
 package frida
 
-//#include <frida-core.h>
+/*
+#include <frida-core.h>
+
+static gchar * get_certificate_pem (GTlsCertificate * cert) {
+	gchar * pem = NULL;
+	g_object_get (cert, "certificate-pem", &pem, NULL);
+	return pem;
+}
+*/
 import "C"
 import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net"
 	"time"
 	"unsafe"
 )
@@ -17,6 +38,109 @@ type Certificate struct {
 	cert *C.GTlsCertificate
 }
 
+// CertificateFromPEM creates the certificate from the PEM encoded data. If data holds the private key
+// as well, the certificate can be used on the server side such as in EParams.
+func CertificateFromPEM(data []byte) (*Certificate, error) {
+	return gTLSCertificateFromPEM(data)
+}
+
+// CertificateFromFile creates the certificate from the PEM file.
+func CertificateFromFile(path string) (*Certificate, error) {
+	return gTLSCertificateFromFile(path)
+}
+
+// CertificateFromTLS creates the certificate from the certificate chain and the private key of cert.
+func CertificateFromTLS(cert *tls.Certificate) (*Certificate, error) {
+	data, err := tlsCertificateToPEM(cert)
+	if err != nil {
+		return nil, err
+	}
+	return gTLSCertificateFromPEM(data)
+}
+
+// GenerateSelfSigned generates the self-signed certificate with the private key valid for the
+// hosts (DNS names or IP addresses) for the validity duration, meant for the test endpoints.
+// The same certificate is used by the server (EParams.Cert) and by the client trusting it
+// (RemoteDeviceOptions.UseCertificate, PortalOptions.UseCertificate).
+func GenerateSelfSigned(hosts []string, validity time.Duration) (*Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	tmpl := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{Organization: []string{"frida-go"}},
+		NotBefore:             now.Add(-time.Minute),
+		NotAfter:              now.Add(validity),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			tmpl.IPAddresses = append(tmpl.IPAddresses, ip)
+		} else {
+			tmpl.DNSNames = append(tmpl.DNSNames, host)
+		}
+	}
+	if len(hosts) > 0 {
+		tmpl.Subject.CommonName = hosts[0]
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return CertificateFromTLS(&tls.Certificate{
+		Certificate: [][]byte{der},
+		PrivateKey:  key,
+	})
+}
+
+// PEM returns the PEM encoded certificate, without the private key.
+func (c *Certificate) PEM() []byte {
+	if c == nil || c.cert == nil {
+		return nil
+	}
+
+	pemC := C.get_certificate_pem(c.cert)
+	defer C.g_free(C.gpointer(pemC))
+	return []byte(C.GoString(pemC))
+}
+
+// X509 parses the certificate.
+func (c *Certificate) X509() (*x509.Certificate, error) {
+	block, _ := pem.Decode(c.PEM())
+	if block == nil {
+		return nil, errors.New("no certificate")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+// Fingerprint returns the hex encoded SHA-256 digest of the DER encoded certificate.
+func (c *Certificate) Fingerprint() (string, error) {
+	cert, err := c.X509()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(cert.Raw)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Clean will clean the resources held by the certificate.
+func (c *Certificate) Clean() {
+	clean(unsafe.Pointer(c.cert), unrefGObject)
+}
+
 // IssuerName returns the issuer name for the certificate.
 func (c *Certificate) IssuerName() string {
 	iss := C.g_tls_certificate_get_issuer_name(c.cert)
@@ -54,3 +178,38 @@ func (c *Certificate) NotValidAfter() (time.Time, error) {
 
 	return time.Parse(timeFormat, C.GoString(cc))
 }
+
+func gTLSCertificateFromPEM(data []byte) (*Certificate, error) {
+	pemC := C.CString(string(data))
+	defer C.free(unsafe.Pointer(pemC))
+
+	var err *C.GError
+	gTLSCert := C.g_tls_certificate_new_from_pem(pemC, C.gssize(len(data)), &err)
+
+	return &Certificate{gTLSCert}, handleGError(err)
+}
+
+// tlsCertificateToPEM encodes the certificate chain and the private key into PEM.
+func tlsCertificateToPEM(cert *tls.Certificate) ([]byte, error) {
+	if len(cert.Certificate) == 0 {
+		return nil, errors.New("tls certificate has no certificates")
+	}
+
+	var buf bytes.Buffer
+	for _, der := range cert.Certificate {
+		if err := pem.Encode(&buf, &pem.Block{Type: "CERTIFICATE", Bytes: der}); err != nil {
+			return nil, err
+		}
+	}
+
+	if cert.PrivateKey != nil {
+		key, err := x509.MarshalPKCS8PrivateKey(cert.PrivateKey)
+		if err != nil {
+			return nil, err
+		}
+		if err := pem.Encode(&buf, &pem.Block{Type: "PRIVATE KEY", Bytes: key}); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
@@ -75,6 +75,10 @@ func goMarshalCls(gclosure *C.GClosure, returnValue *C.GValue, nParams C.guint,
 	fnArgs := make([]reflect.Value, fnCountArgs)
 
 	for i := 0; i < fnCountArgs; i++ {
+		if fnType.In(i) == rawVariantType {
+			fnArgs[i] = reflect.ValueOf(rawVariant{C.g_value_get_variant(&gvalues[i+1])})
+			continue
+		}
 		goV := GValueToGo(&gvalues[i+1])
 		fnArgs[i] = reflect.ValueOf(goV).Convert(fnType.In(i))
 	}
@@ -82,12 +86,22 @@ func goMarshalCls(gclosure *C.GClosure, returnValue *C.GValue, nParams C.guint,
 	closure.Func.Call(fnArgs)
 }
 
+// rawVariant is passed to the handlers declaring it as the parameter instead of the
+// converted go value, the variant is only valid until the handler returns.
+type rawVariant struct {
+	v *C.GVariant
+}
+
+var rawVariantType = reflect.TypeOf(rawVariant{})
+
 type funcstack struct {
 	Func   reflect.Value
 	Frames []uintptr
 }
 
-func connectClosure(obj unsafe.Pointer, sigName string, fn any) {
+// connectClosure connects fn to the sigName signal of obj and returns the handler id
+// which can be passed to disconnectClosure; 0 is returned if there is no such signal.
+func connectClosure(obj unsafe.Pointer, sigName string, fn any) C.gulong {
 	v := reflect.ValueOf(fn)
 
 	if v.Type().Kind() != reflect.Func {
@@ -110,7 +124,14 @@ func connectClosure(obj unsafe.Pointer, sigName string, fn any) {
 
 	// Do nothing if signal is 0 meaning not found
 	if int(sigID) != 0 {
-		C.g_signal_connect_closure_by_id((C.gpointer)(obj), sigID, 0, gclosure, C.gboolean(1))
+		return C.g_signal_connect_closure_by_id((C.gpointer)(obj), sigID, 0, gclosure, C.gboolean(1))
+	}
+	return 0
+}
+
+func disconnectClosure(obj unsafe.Pointer, handlerID C.gulong) {
+	if obj != nil && handlerID != 0 {
+		C.g_signal_handler_disconnect((C.gpointer)(obj), handlerID)
 	}
 }
 
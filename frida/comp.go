@@ -4,53 +4,58 @@ package frida
  */
 import "C"
 import (
+	"context"
+	"fmt"
 	"reflect"
+	"strings"
+	"sync"
 	"unsafe"
 )
 
-// CompilerOptions represent options passed to compiler to build/watch.
-type CompilerOptions struct {
-	c *C.FridaCompilerOptions
+// Diagnostic represents single diagnostic emitted by the compiler.
+// Path, Line and Character are empty if the diagnostic is not related to the file.
+type Diagnostic struct {
+	Category  string
+	Code      int
+	Path      string
+	Line      int
+	Character int
+	Text      string
 }
 
-// NewCompilerOptions creates new compiler options.
-func NewCompilerOptions() *CompilerOptions {
-	c := C.frida_compiler_options_new()
-	return &CompilerOptions{c: c}
-}
-
-// SetProjectRoot sets the project root, you would use this if your entrypoint
-// script is in another directory besides the current one.
-func (c *CompilerOptions) SetProjectRoot(projectRoot string) {
-	pRoot := C.CString(projectRoot)
-	defer C.free(unsafe.Pointer(pRoot))
-
-	C.frida_compiler_options_set_project_root(c.c, pRoot)
-}
-
-// SetJSCompression allows you to choose compression for generated file.
-func (c *CompilerOptions) SetJSCompression(compress JSCompressionType) {
-	C.frida_compiler_options_set_compression(c.c, (C.FridaJsCompression)(compress))
+// String returns the diagnostic in the path:line:character format used by tsc.
+func (d Diagnostic) String() string {
+	if d.Path == "" {
+		return fmt.Sprintf("%s TS%d: %s", d.Category, d.Code, d.Text)
+	}
+	return fmt.Sprintf("%s:%d:%d - %s TS%d: %s", d.Path, d.Line, d.Character, d.Category, d.Code, d.Text)
 }
 
-// SetSourceMaps allows you to choose whether you want source maps included or omitted.
-func (c *CompilerOptions) SetSourceMaps(sourceMaps SourceMaps) {
-	C.frida_compiler_options_set_source_maps(c.c, (C.FridaSourceMaps)(sourceMaps))
-}
+// BuildEventType represents the type of the BuildEvent.
+type BuildEventType int
 
-// SetOutputFormat allows to dictate which output format.
-func (c *CompilerOptions) SetOutputFormat(outputFormat OutputFormat) {
-	C.frida_compiler_options_set_output_format(c.c, (C.FridaOutputFormat)(outputFormat))
-}
+const (
+	BuildEventStarting BuildEventType = iota
+	BuildEventFinished
+	BuildEventOutput
+	BuildEventDiagnostics
+	BuildEventError
+)
 
-// SetBundleFormat allows to choose bundle format.
-func (c *CompilerOptions) SetBundleFormat(bundleFormat BundleFormat) {
-	C.frida_compiler_options_set_bundle_format(c.c, (C.FridaBundleFormat)(bundleFormat))
+func (b BuildEventType) String() string {
+	return [...]string{"starting",
+		"finished",
+		"output",
+		"diagnostics",
+		"error"}[b]
 }
 
-// SetTypeCheckMode allows to set which type checking option to have while compiling.
-func (c *CompilerOptions) SetTypeCheckMode(typeCheckMode TypeCheckMode) {
-	C.frida_compiler_options_set_type_check(c.c, (C.FridaTypeCheckMode)(typeCheckMode))
+// BuildEvent is sent by Compiler.Watch for each of the compiler signals.
+type BuildEvent struct {
+	Type        BuildEventType
+	Bundle      string       // populated for BuildEventOutput
+	Diagnostics []Diagnostic // populated for BuildEventDiagnostics
+	Err         error        // populated for BuildEventError
 }
 
 // Compiler type is used to compile scripts.
@@ -70,13 +75,13 @@ func NewCompiler() *Compiler {
 }
 
 // Build builds the script from the entrypoint.
-func (c *Compiler) Build(entrypoint string, opts *CompilerOptions) (string, error) {
+func (c *Compiler) Build(entrypoint string, opts *BuildOptions) (string, error) {
 	entrypointC := C.CString(entrypoint)
 	defer C.free(unsafe.Pointer(entrypointC))
 
 	var o *C.FridaBuildOptions = nil
 	if opts != nil {
-		o = (*C.FridaBuildOptions)(opts.c)
+		o = opts.b
 	}
 
 	var err *C.GError
@@ -84,18 +89,88 @@ func (c *Compiler) Build(entrypoint string, opts *CompilerOptions) (string, erro
 	return C.GoString(ret), handleGError(err)
 }
 
-// Watch watches for changes at the entrypoint and sends the "output" signal.
-func (c *Compiler) Watch(entrypoint string, opts *CompilerOptions) error {
+// Watch watches for changes at the entrypoint and sends the events for every rebuild on the returned channel
+// until ctx is done, then the events stop and the channel gets closed. If watching could not be started,
+// BuildEventError is sent and the channel gets closed once it's read.
+// frida has no way to stop watching, so the compiler keeps watching the files after ctx is done
+// until it is cleaned with Clean.
+//
+// Example:
+//
+//	for ev := range compiler.Watch(ctx, "agent.ts", nil) {
+//		switch ev.Type {
+//		case frida.BuildEventOutput:
+//			fmt.Println("bundle:", len(ev.Bundle))
+//		case frida.BuildEventDiagnostics:
+//			for _, diag := range ev.Diagnostics {
+//				fmt.Println(diag)
+//			}
+//		}
+//	}
+func (c *Compiler) Watch(ctx context.Context, entrypoint string, opts *WatchOptions) <-chan BuildEvent {
+	q := newEventQueue[BuildEvent]()
+
+	handlers := []C.gulong{
+		connectClosure(unsafe.Pointer(c.cc), "starting", func() {
+			q.push(BuildEvent{Type: BuildEventStarting})
+		}),
+		connectClosure(unsafe.Pointer(c.cc), "finished", func() {
+			q.push(BuildEvent{Type: BuildEventFinished})
+		}),
+		connectClosure(unsafe.Pointer(c.cc), "output", func(bundle string) {
+			q.push(BuildEvent{Type: BuildEventOutput, Bundle: bundle})
+		}),
+		connectClosure(unsafe.Pointer(c.cc), "diagnostics", func(diags rawVariant) {
+			q.push(BuildEvent{Type: BuildEventDiagnostics, Diagnostics: diagnosticsFromVariant(diags)})
+		}),
+	}
+
+	var once sync.Once
+	disconnect := func() {
+		once.Do(func() {
+			for _, id := range handlers {
+				disconnectClosure(unsafe.Pointer(c.cc), id)
+			}
+		})
+	}
+
+	// watch returns once the watching has started, the handlers stay connected
+	// for the rebuilds until ctx is done
+	cancel := NewCancellable()
+	failed := make(chan struct{})
+	go func() {
+		if err := c.watch(entrypoint, opts, options{cancellable: cancel.cancellable}); err != nil {
+			q.push(BuildEvent{Type: BuildEventError, Err: err})
+			disconnect()
+			q.drain()
+			close(failed)
+		}
+	}()
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			cancel.Cancel()
+			disconnect()
+			q.close()
+		case <-failed:
+		}
+	}()
+
+	return q.events()
+}
+
+func (c *Compiler) watch(entrypoint string, opts *WatchOptions, o options) error {
 	entrypointC := C.CString(entrypoint)
 	defer C.free(unsafe.Pointer(entrypointC))
 
-	var o *C.FridaWatchOptions = nil
+	var wo *C.FridaWatchOptions = nil
 	if opts != nil {
-		o = (*C.FridaWatchOptions)(opts.c)
+		wo = opts.w
 	}
 
 	var err *C.GError
-	C.frida_compiler_watch_sync(c.cc, entrypointC, o, nil, &err)
+	C.frida_compiler_watch_sync(c.cc, entrypointC, wo, o.cancellable, &err)
 	return handleGError(err)
 }
 
@@ -111,10 +186,11 @@ func (c *Compiler) Clean() {
 //   - "starting" with callback as func() {}
 //   - "finished" with callback as func() {}
 //   - "output" with callback as func(bundle string) {}
-//   - "diagnostics" with callback as func(diag string) {}
+//   - "diagnostics" with callback as func(diags []frida.Diagnostic) {} or func(diag string) {}
+//     to receive only the texts joined with new lines
 //   - "file_changed" with callback as func() {}
 func (c *Compiler) On(sigName string, fn any) {
-	// hijack diagnostics and pass only text
+	// hijack diagnostics to convert them into Diagnostic
 	if sigName == "diagnostics" {
 		c.fn = reflect.ValueOf(fn)
 		connectClosure(unsafe.Pointer(c.cc), sigName, c.hijackFn)
@@ -123,8 +199,70 @@ func (c *Compiler) On(sigName string, fn any) {
 	}
 }
 
-func (c *Compiler) hijackFn(diag map[string]any) {
-	text := diag["text"].(string)
-	args := []reflect.Value{reflect.ValueOf(text)}
-	c.fn.Call(args)
+func (c *Compiler) hijackFn(diags rawVariant) {
+	diagnostics := diagnosticsFromVariant(diags)
+
+	var arg reflect.Value
+	if c.fn.Type().NumIn() > 0 && c.fn.Type().In(0).Kind() == reflect.String {
+		texts := make([]string, len(diagnostics))
+		for i, diag := range diagnostics {
+			texts[i] = diag.Text
+		}
+		arg = reflect.ValueOf(strings.Join(texts, "\n"))
+	} else {
+		arg = reflect.ValueOf(diagnostics)
+	}
+
+	if c.fn.Type().NumIn() == 0 {
+		c.fn.Call(nil)
+		return
+	}
+	c.fn.Call([]reflect.Value{arg})
+}
+
+// diagnosticsFromVariant converts the diagnostics signal parameter (aa{sv}) into Diagnostic slice.
+func diagnosticsFromVariant(diags rawVariant) []Diagnostic {
+	var raw []any
+	if diags.v != nil {
+		raw = gVariantChildrenToGo(diags.v)
+	}
+	diagnostics := make([]Diagnostic, 0, len(raw))
+
+	for _, r := range raw {
+		d, ok := r.(map[string]any)
+		if !ok {
+			continue
+		}
+
+		var diag Diagnostic
+		diag.Category, _ = d["category"].(string)
+		diag.Code = toInt(d["code"])
+		diag.Text, _ = d["text"].(string)
+
+		if file, ok := d["file"].(map[string]any); ok {
+			diag.Path, _ = file["path"].(string)
+			diag.Line = toInt(file["line"])
+			diag.Character = toInt(file["character"])
+		}
+
+		diagnostics = append(diagnostics, diag)
+	}
+
+	return diagnostics
+}
+
+func toInt(v any) int {
+	switch n := v.(type) {
+	case int:
+		return n
+	case int64:
+		return int(n)
+	case uint:
+		return int(n)
+	case uint64:
+		return int(n)
+	case float64:
+		return int(n)
+	}
+	return 0
 }
@@ -0,0 +1,147 @@
+package frida
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// StagedProject is the temporary project root holding the files copied from fs.FS
+// so that the compiler can build them.
+type StagedProject struct {
+	root string
+}
+
+// StageProject copies the files from src into new temporary directory. If nodeModules
+// is not nil, its contents are copied into the node_modules directory of the project.
+// Call Remove once the project is not needed anymore. Symlinks are not supported as fs.FS
+// doesn't expose their targets, resolve them before passing the filesystems in; the symlinks
+// npm creates in node_modules/.bin are skipped as the build doesn't need them.
+func StageProject(src, nodeModules fs.FS) (*StagedProject, error) {
+	if src == nil {
+		return nil, errors.New("you need to provide source filesystem")
+	}
+
+	root, err := os.MkdirTemp("", "frida-go-project-*")
+	if err != nil {
+		return nil, err
+	}
+	p := &StagedProject{root: root}
+
+	if err := copyFS(root, src, false); err != nil {
+		p.Remove()
+		return nil, err
+	}
+	if nodeModules != nil {
+		if err := copyFS(filepath.Join(root, "node_modules"), nodeModules, true); err != nil {
+			p.Remove()
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// Root returns the path of the project root.
+func (p *StagedProject) Root() string {
+	return p.root
+}
+
+// Path returns the path on disk of the slash-separated name inside the project.
+func (p *StagedProject) Path(name string) string {
+	return filepath.Join(p.root, filepath.FromSlash(name))
+}
+
+// Remove removes the project root with all its files.
+func (p *StagedProject) Remove() error {
+	return os.RemoveAll(p.root)
+}
+
+// BuildFS builds the script from the entrypoint inside src, entrypoint is slash-separated
+// path relative to the root of src. nodeModules is optional tree copied as node_modules.
+// Files are staged in the temporary project root which is removed once the build is done.
+// The build uses the copy of opts with the project root set to the staged root, opts are left intact.
+func (c *Compiler) BuildFS(src, nodeModules fs.FS, entrypoint string, opts *BuildOptions) (string, error) {
+	if src == nil {
+		return "", errors.New("you need to provide source filesystem")
+	}
+	if !fs.ValidPath(entrypoint) {
+		return "", fmt.Errorf("invalid entrypoint %q", entrypoint)
+	}
+	if _, err := fs.Stat(src, entrypoint); err != nil {
+		return "", err
+	}
+
+	p, err := StageProject(src, nodeModules)
+	if err != nil {
+		return "", err
+	}
+	defer p.Remove()
+
+	var staged *BuildOptions
+	if opts == nil {
+		staged = NewBuildOptions()
+	} else {
+		staged = opts.copy()
+	}
+	defer staged.Clean()
+	staged.SetProjectRoot(p.Root())
+
+	return c.Build(p.Path(entrypoint), staged)
+}
+
+// copyFS copies the regular files and the directories of src into dst. fs.FS can't tell
+// where the symlinks point to, so they are rejected rather than risking copying the files
+// from outside of src; the ones in node_modules/.bin are skipped. nodeModules tells whether
+// src is the node_modules tree itself.
+func copyFS(dst string, src fs.FS, nodeModules bool) error {
+	return fs.WalkDir(src, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		target := filepath.Join(dst, filepath.FromSlash(name))
+		if d.IsDir() {
+			return os.MkdirAll(target, 0o755)
+		}
+		if d.Type()&fs.ModeSymlink != 0 && isNodeModulesBin(name, nodeModules) {
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return fmt.Errorf("%s: unsupported file type %s", name, d.Type())
+		}
+		return copyFSFile(target, src, name)
+	})
+}
+
+// isNodeModulesBin returns whether the slash-separated name is inside the .bin directory
+// of node_modules, holding the links to the package executables.
+func isNodeModulesBin(name string, nodeModules bool) bool {
+	dir := path.Dir(name)
+	if nodeModules && dir == ".bin" {
+		return true
+	}
+	return dir == "node_modules/.bin" || strings.HasSuffix(dir, "/node_modules/.bin")
+}
+
+func copyFSFile(target string, src fs.FS, name string) error {
+	in, err := src.Open(name)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,98 @@
+package frida
+
+import (
+	"io/fs"
+	"os"
+	"testing"
+	"testing/fstest"
+)
+
+func TestStageProject(t *testing.T) {
+	src := fstest.MapFS{
+		"agent.ts":                        {Data: []byte("import './lib/a';")},
+		"lib/a.ts":                        {Data: []byte("send(1);")},
+		"node_modules/.bin/tsc":           {Data: []byte("../typescript/bin/tsc"), Mode: fs.ModeSymlink},
+		"node_modules/typescript/bin/tsc": {Data: []byte("#!/usr/bin/env node")},
+	}
+	nodeModules := fstest.MapFS{
+		".bin/esbuild":                   {Data: []byte("../esbuild/bin/esbuild"), Mode: fs.ModeSymlink},
+		"esbuild/bin/esbuild":            {Data: []byte("#!/usr/bin/env node")},
+		"pkg/node_modules/.bin/helper":   {Data: []byte("../helper/cli.js"), Mode: fs.ModeSymlink},
+		"pkg/node_modules/helper/cli.js": {Data: []byte("cli")},
+		"pkg/index.js":                   {Data: []byte("module.exports = 1;")},
+	}
+
+	p, err := StageProject(src, nodeModules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Remove()
+
+	files := map[string]string{
+		"agent.ts":                                    "import './lib/a';",
+		"lib/a.ts":                                    "send(1);",
+		"node_modules/typescript/bin/tsc":             "#!/usr/bin/env node",
+		"node_modules/esbuild/bin/esbuild":            "#!/usr/bin/env node",
+		"node_modules/pkg/index.js":                   "module.exports = 1;",
+		"node_modules/pkg/node_modules/helper/cli.js": "cli",
+	}
+	for name, want := range files {
+		got, err := os.ReadFile(p.Path(name))
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if string(got) != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+
+	for _, name := range []string{
+		"node_modules/.bin/tsc",
+		"node_modules/.bin/esbuild",
+		"node_modules/pkg/node_modules/.bin/helper",
+	} {
+		if _, err := os.Lstat(p.Path(name)); err == nil {
+			t.Errorf("%s: symlink was staged", name)
+		}
+	}
+}
+
+func TestStageProjectRejected(t *testing.T) {
+	tests := []struct {
+		name        string
+		src         fs.FS
+		nodeModules fs.FS
+	}{
+		{name: "nil source"},
+		{
+			name: "symlink in source",
+			src: fstest.MapFS{
+				"agent.ts":  {Data: []byte("send(1);")},
+				"secret.ts": {Data: []byte("/etc/passwd"), Mode: fs.ModeSymlink},
+			},
+		},
+		{
+			name: "symlink in node_modules package",
+			src:  fstest.MapFS{"agent.ts": {Data: []byte("send(1);")}},
+			nodeModules: fstest.MapFS{
+				"pkg/index.js": {Data: []byte("../../outside.js"), Mode: fs.ModeSymlink},
+			},
+		},
+		{
+			name: ".bin outside of node_modules",
+			src: fstest.MapFS{
+				"agent.ts":  {Data: []byte("send(1);")},
+				".bin/tool": {Data: []byte("/usr/bin/tool"), Mode: fs.ModeSymlink},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		p, err := StageProject(tt.src, tt.nodeModules)
+		if err == nil {
+			p.Remove()
+			t.Errorf("%s: StageProject() succeeded", tt.name)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package frida
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	compiledScriptMagic   = "FRGC"
+	compiledScriptVersion = 1
+)
+
+var (
+	// ErrIncompatibleBytecode is returned when the compiled script can't be loaded
+	// by the frida version of the target.
+	ErrIncompatibleBytecode = errors.New("compiled script is not compatible with target frida")
+	// ErrSourceMismatch is returned when the compiled script was compiled from different source.
+	ErrSourceMismatch = errors.New("compiled script was compiled from different source")
+)
+
+// CompiledScript is the artifact holding the bytecode returned by Session.CompileScript
+// together with the metadata needed to decide whether it can still be loaded.
+// It implements encoding.BinaryMarshaler and encoding.BinaryUnmarshaler so it can be
+// stored on disk or embedded into the binary.
+type CompiledScript struct {
+	Bytecode  []byte
+	SourceMap []byte
+	Runtime   ScriptRuntime
+	// FridaVersion is the version of the frida the bytecode was compiled by. CompileScriptArtifact sets
+	// it to the local Version(); set it to the target's version when the session is on the target
+	// running another frida, as the bytecode is compiled by the target's agent.
+	FridaVersion string
+	SourceHash   string
+}
+
+// CompileScriptArtifact compiles the source and wraps the bytecode into CompiledScript.
+// sourceMap is optional and stored as is.
+func (s *Session) CompileScriptArtifact(source string, sourceMap []byte, opts *ScriptOptions) (*CompiledScript, error) {
+	bytecode, err := s.CompileScript(source, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	rt := ScriptRuntimeDefault
+	if opts != nil {
+		rt = opts.Runtime()
+	}
+
+	return &CompiledScript{
+		Bytecode:     bytecode,
+		SourceMap:    sourceMap,
+		Runtime:      rt,
+		FridaVersion: Version(),
+		SourceHash:   hashScript(source),
+	}, nil
+}
+
+// CheckCompatible returns ErrIncompatibleBytecode if the script was compiled by another frida version
+// than targetVersion and ErrSourceMismatch if source is not empty and differs from the one the script
+// was compiled from. The bytecode is run by the frida-agent of the target, so targetVersion is the frida
+// version of the target, e.g. of the frida-server on the remote device; empty targetVersion stands for
+// the local Version(), which is the version of the agent on the local device.
+func (c *CompiledScript) CheckCompatible(targetVersion, source string) error {
+	if len(c.Bytecode) == 0 {
+		return fmt.Errorf("%w: no bytecode", ErrIncompatibleBytecode)
+	}
+	if targetVersion == "" {
+		targetVersion = Version()
+	}
+	if c.FridaVersion != targetVersion {
+		return fmt.Errorf("%w: compiled with %s, target runs %s", ErrIncompatibleBytecode, c.FridaVersion, targetVersion)
+	}
+	if source != "" && c.SourceHash != hashScript(source) {
+		return ErrSourceMismatch
+	}
+	return nil
+}
+
+// CreateScriptFromCompiled creates the script from the compiled bytecode if it's compatible
+// with the source and the local frida version, see CheckCompatible; otherwise the script is created from the source.
+// For the target running another frida, e.g. the remote frida-server, call CheckCompatible with its
+// version and CreateScriptBytes instead.
+// If source is empty and the bytecode is stale, the compatibility error is returned.
+// The source map of the compiled script, if any, is set on the created script.
+func (s *Session) CreateScriptFromCompiled(compiled *CompiledScript, source string, opts *ScriptOptions) (*Script, error) {
+	if compiled == nil {
+		return nil, errors.New("you need to provide compiled script")
+	}
+
+	if err := compiled.CheckCompatible("", source); err != nil {
+		if source == "" {
+			return nil, err
+		}
+		return s.CreateScriptWithOptions(source, opts)
+	}
+
+	// the runtime is set on the copy as the caller owns opts
+	config := &ScriptConfig{Name: "frida-go"}
+	if opts != nil {
+		config = opts.Config()
+	}
+	config.Runtime = compiled.Runtime
+
+	bytesOpts, err := NewScriptOptionsFromConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	defer bytesOpts.Clean()
+
+	sc, err := s.CreateScriptBytes(compiled.Bytecode, bytesOpts)
+	if err != nil {
+		return sc, err
+	}
+
+	if len(compiled.SourceMap) > 0 {
+		if sm, err := ParseSourceMap(compiled.SourceMap); err == nil {
+			sc.SetSourceMap(sm)
+		}
+	}
+	return sc, nil
+}
+
+// MarshalBinary encodes the compiled script.
+func (c *CompiledScript) MarshalBinary() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(compiledScriptMagic)
+
+	binary.Write(&buf, binary.LittleEndian, uint16(compiledScriptVersion))
+	binary.Write(&buf, binary.LittleEndian, uint16(c.Runtime))
+
+	for _, field := range [][]byte{
+		[]byte(c.FridaVersion),
+		[]byte(c.SourceHash),
+		c.SourceMap,
+		c.Bytecode,
+	} {
+		binary.Write(&buf, binary.LittleEndian, uint32(len(field)))
+		buf.Write(field)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary decodes the compiled script previously encoded with MarshalBinary.
+func (c *CompiledScript) UnmarshalBinary(data []byte) error {
+	r := bytes.NewReader(data)
+
+	magic := make([]byte, len(compiledScriptMagic))
+	if _, err := io.ReadFull(r, magic); err != nil || string(magic) != compiledScriptMagic {
+		return errors.New("not a compiled script")
+	}
+
+	var version, rt uint16
+	if err := binary.Read(r, binary.LittleEndian, &version); err != nil {
+		return err
+	}
+	if version != compiledScriptVersion {
+		return fmt.Errorf("unsupported compiled script format version %d", version)
+	}
+	if err := binary.Read(r, binary.LittleEndian, &rt); err != nil {
+		return err
+	}
+
+	fields := make([][]byte, 4)
+	for i := range fields {
+		var sz uint32
+		if err := binary.Read(r, binary.LittleEndian, &sz); err != nil {
+			return err
+		}
+		if int64(sz) > int64(r.Len()) {
+			return io.ErrUnexpectedEOF
+		}
+		fields[i] = make([]byte, sz)
+		if _, err := io.ReadFull(r, fields[i]); err != nil {
+			return err
+		}
+	}
+
+	c.Runtime = ScriptRuntime(rt)
+	c.FridaVersion = string(fields[0])
+	c.SourceHash = string(fields[1])
+	c.SourceMap = fields[2]
+	c.Bytecode = fields[3]
+	if len(c.SourceMap) == 0 {
+		c.SourceMap = nil
+	}
+
+	return nil
+}
@@ -0,0 +1,128 @@
+package frida
+
+import (
+	"encoding/binary"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestCompiledScriptMarshalRoundTrip(t *testing.T) {
+	tests := []struct {
+		name     string
+		compiled CompiledScript
+	}{
+		{
+			name: "full",
+			compiled: CompiledScript{
+				Bytecode:     []byte{0x00, 0x01, 0xfe, 0xff},
+				SourceMap:    []byte(`{"version":3}`),
+				Runtime:      ScriptRuntimeQJS,
+				FridaVersion: "16.1.4",
+				SourceHash:   hashScript("send(1)"),
+			},
+		},
+		{
+			name: "no source map",
+			compiled: CompiledScript{
+				Bytecode:     []byte("bytecode"),
+				Runtime:      ScriptRuntimeDefault,
+				FridaVersion: "16.1.4",
+				SourceHash:   hashScript(""),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		data, err := tt.compiled.MarshalBinary()
+		if err != nil {
+			t.Fatalf("%s: MarshalBinary() error = %v", tt.name, err)
+		}
+
+		var got CompiledScript
+		if err := got.UnmarshalBinary(data); err != nil {
+			t.Fatalf("%s: UnmarshalBinary() error = %v", tt.name, err)
+		}
+		if !reflect.DeepEqual(got, tt.compiled) {
+			t.Errorf("%s: round trip = %+v, want %+v", tt.name, got, tt.compiled)
+		}
+	}
+}
+
+func TestCompiledScriptUnmarshalCorrupted(t *testing.T) {
+	compiled := CompiledScript{
+		Bytecode:     []byte("bytecode"),
+		SourceMap:    []byte("map"),
+		Runtime:      ScriptRuntimeV8,
+		FridaVersion: "16.1.4",
+		SourceHash:   hashScript("source"),
+	}
+	data, err := compiled.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// every truncation of the valid encoding must be rejected
+	for n := 0; n < len(data); n++ {
+		var got CompiledScript
+		if err := got.UnmarshalBinary(data[:n]); err == nil {
+			t.Errorf("UnmarshalBinary() of %d of %d bytes succeeded", n, len(data))
+		}
+	}
+
+	header := len(compiledScriptMagic) + 4
+
+	badMagic := append([]byte{}, data...)
+	copy(badMagic, "XXXX")
+
+	badVersion := append([]byte{}, data...)
+	binary.LittleEndian.PutUint16(badVersion[len(compiledScriptMagic):], compiledScriptVersion+1)
+
+	hugeField := append([]byte{}, data...)
+	binary.LittleEndian.PutUint32(hugeField[header:], 0xffffffff)
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{name: "empty", data: nil},
+		{name: "bad magic", data: badMagic},
+		{name: "unsupported version", data: badVersion},
+		{name: "field longer than data", data: hugeField},
+	}
+
+	for _, tt := range tests {
+		var got CompiledScript
+		if err := got.UnmarshalBinary(tt.data); err == nil {
+			t.Errorf("%s: UnmarshalBinary() succeeded", tt.name)
+		}
+	}
+}
+
+func TestCompiledScriptCheckCompatible(t *testing.T) {
+	compiled := &CompiledScript{
+		Bytecode:     []byte("bytecode"),
+		FridaVersion: "16.1.4",
+		SourceHash:   hashScript("source"),
+	}
+
+	tests := []struct {
+		name     string
+		compiled *CompiledScript
+		target   string
+		source   string
+		want     error
+	}{
+		{name: "same version", compiled: compiled, target: "16.1.4"},
+		{name: "same source", compiled: compiled, target: "16.1.4", source: "source"},
+		{name: "other version", compiled: compiled, target: "16.2.0", want: ErrIncompatibleBytecode},
+		{name: "other source", compiled: compiled, target: "16.1.4", source: "changed", want: ErrSourceMismatch},
+		{name: "no bytecode", compiled: &CompiledScript{FridaVersion: "16.1.4"}, target: "16.1.4", want: ErrIncompatibleBytecode},
+	}
+
+	for _, tt := range tests {
+		if err := tt.compiled.CheckCompatible(tt.target, tt.source); !errors.Is(err, tt.want) {
+			t.Errorf("%s: CheckCompatible() error = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package frida
+
+//#include <frida-core.h>
+import "C"
+import "unsafe"
+
+// CompilerOptions holds the options shared by BuildOptions and WatchOptions.
+// It is not created directly, use NewBuildOptions or NewWatchOptions instead.
+type CompilerOptions struct {
+	c         *C.FridaCompilerOptions
+	externals []string
+}
+
+// SetProjectRoot sets the project root, you would use this if your entrypoint
+// script is in another directory besides the current one.
+func (c *CompilerOptions) SetProjectRoot(projectRoot string) {
+	pRoot := C.CString(projectRoot)
+	defer C.free(unsafe.Pointer(pRoot))
+
+	C.frida_compiler_options_set_project_root(c.c, pRoot)
+}
+
+// ProjectRoot returns the project root.
+func (c *CompilerOptions) ProjectRoot() string {
+	return C.GoString(C.frida_compiler_options_get_project_root(c.c))
+}
+
+// SetJSCompression allows you to choose compression for generated file.
+func (c *CompilerOptions) SetJSCompression(compress JSCompressionType) {
+	C.frida_compiler_options_set_compression(c.c, (C.FridaJsCompression)(compress))
+}
+
+// JSCompression returns the compression for generated file.
+func (c *CompilerOptions) JSCompression() JSCompressionType {
+	return JSCompressionType(C.frida_compiler_options_get_compression(c.c))
+}
+
+// SetSourceMaps allows you to choose whether you want source maps included or omitted.
+func (c *CompilerOptions) SetSourceMaps(sourceMaps SourceMaps) {
+	C.frida_compiler_options_set_source_maps(c.c, (C.FridaSourceMaps)(sourceMaps))
+}
+
+// SourceMaps returns whether the source maps are included or omitted.
+func (c *CompilerOptions) SourceMaps() SourceMaps {
+	return SourceMaps(C.frida_compiler_options_get_source_maps(c.c))
+}
+
+// SetOutputFormat allows to dictate which output format.
+func (c *CompilerOptions) SetOutputFormat(outputFormat OutputFormat) {
+	C.frida_compiler_options_set_output_format(c.c, (C.FridaOutputFormat)(outputFormat))
+}
+
+// OutputFormat returns the output format.
+func (c *CompilerOptions) OutputFormat() OutputFormat {
+	return OutputFormat(C.frida_compiler_options_get_output_format(c.c))
+}
+
+// SetBundleFormat allows to choose bundle format.
+func (c *CompilerOptions) SetBundleFormat(bundleFormat BundleFormat) {
+	C.frida_compiler_options_set_bundle_format(c.c, (C.FridaBundleFormat)(bundleFormat))
+}
+
+// BundleFormat returns the bundle format.
+func (c *CompilerOptions) BundleFormat() BundleFormat {
+	return BundleFormat(C.frida_compiler_options_get_bundle_format(c.c))
+}
+
+// SetTypeCheckMode allows to set which type checking option to have while compiling.
+func (c *CompilerOptions) SetTypeCheckMode(typeCheckMode TypeCheckMode) {
+	C.frida_compiler_options_set_type_check(c.c, (C.FridaTypeCheckMode)(typeCheckMode))
+}
+
+// TypeCheckMode returns the type checking mode.
+func (c *CompilerOptions) TypeCheckMode() TypeCheckMode {
+	return TypeCheckMode(C.frida_compiler_options_get_type_check(c.c))
+}
+
+// SetPlatform sets the platform the bundle is built for.
+func (c *CompilerOptions) SetPlatform(platform JsPlatform) {
+	C.frida_compiler_options_set_platform(c.c, (C.FridaJsPlatform)(platform))
+}
+
+// Platform returns the platform the bundle is built for.
+func (c *CompilerOptions) Platform() JsPlatform {
+	return JsPlatform(C.frida_compiler_options_get_platform(c.c))
+}
+
+// AddExternal marks the module as external so it is not included in the bundle.
+func (c *CompilerOptions) AddExternal(external string) {
+	externalC := C.CString(external)
+	defer C.free(unsafe.Pointer(externalC))
+
+	C.frida_compiler_options_add_external(c.c, externalC)
+	c.externals = append(c.externals, external)
+}
+
+// ClearExternals removes previously added externals.
+func (c *CompilerOptions) ClearExternals() {
+	C.frida_compiler_options_clear_externals(c.c)
+	c.externals = nil
+}
+
+// Externals returns the externals added with AddExternal.
+func (c *CompilerOptions) Externals() []string {
+	externals := make([]string, len(c.externals))
+	copy(externals, c.externals)
+	return externals
+}
+
+// copyTo sets the options of c on dst.
+func (c *CompilerOptions) copyTo(dst *CompilerOptions) {
+	dst.SetProjectRoot(c.ProjectRoot())
+	dst.SetJSCompression(c.JSCompression())
+	dst.SetSourceMaps(c.SourceMaps())
+	dst.SetOutputFormat(c.OutputFormat())
+	dst.SetBundleFormat(c.BundleFormat())
+	dst.SetTypeCheckMode(c.TypeCheckMode())
+	dst.SetPlatform(c.Platform())
+	for _, external := range c.externals {
+		dst.AddExternal(external)
+	}
+}
+
+// Clean will clean the resources held by the options.
+func (c *CompilerOptions) Clean() {
+	clean(unsafe.Pointer(c.c), unrefFrida)
+}
+
+// BuildOptions represent options passed to Compiler.Build.
+type BuildOptions struct {
+	CompilerOptions
+	b *C.FridaBuildOptions
+}
+
+// NewBuildOptions creates new build options.
+func NewBuildOptions() *BuildOptions {
+	b := C.frida_build_options_new()
+	return &BuildOptions{
+		CompilerOptions: CompilerOptions{c: (*C.FridaCompilerOptions)(unsafe.Pointer(b))},
+		b:               b,
+	}
+}
+
+// copy returns new build options holding the same values as b.
+func (b *BuildOptions) copy() *BuildOptions {
+	o := NewBuildOptions()
+	b.copyTo(&o.CompilerOptions)
+	return o
+}
+
+// WatchOptions represent options passed to Compiler.Watch.
+type WatchOptions struct {
+	CompilerOptions
+	w *C.FridaWatchOptions
+}
+
+// NewWatchOptions creates new watch options.
+func NewWatchOptions() *WatchOptions {
+	w := C.frida_watch_options_new()
+	return &WatchOptions{
+		CompilerOptions: CompilerOptions{c: (*C.FridaCompilerOptions)(unsafe.Pointer(w))},
+		w:               w,
+	}
+}
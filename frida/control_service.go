@@ -0,0 +1,200 @@
+package frida
+
+//#include <frida-core.h>
+import "C"
+import (
+	"context"
+	"unsafe"
+)
+
+// ControlServiceOptions represent options passed to NewControlService.
+type ControlServiceOptions struct {
+	opts *C.FridaControlServiceOptions
+}
+
+// NewControlServiceOptions creates new control service options.
+func NewControlServiceOptions() *ControlServiceOptions {
+	opts := C.frida_control_service_options_new()
+	return &ControlServiceOptions{
+		opts: opts,
+	}
+}
+
+// SetSysroot sets the sysroot, the same as the --sysroot option of frida-server.
+func (c *ControlServiceOptions) SetSysroot(sysroot string) {
+	sysrootC := C.CString(sysroot)
+	defer C.free(unsafe.Pointer(sysrootC))
+
+	C.frida_control_service_options_set_sysroot(c.opts, sysrootC)
+}
+
+// Sysroot returns the sysroot.
+func (c *ControlServiceOptions) Sysroot() string {
+	return C.GoString(C.frida_control_service_options_get_sysroot(c.opts))
+}
+
+// SetEnablePreload sets whether the preload is enabled, the same as the --disable-preload option of frida-server.
+func (c *ControlServiceOptions) SetEnablePreload(enable bool) {
+	C.frida_control_service_options_set_enable_preload(c.opts, goBoolToGBoolean(enable))
+}
+
+// EnablePreload returns whether the preload is enabled.
+func (c *ControlServiceOptions) EnablePreload() bool {
+	return C.frida_control_service_options_get_enable_preload(c.opts) != 0
+}
+
+// SetReportCrashes sets whether the crashes are reported, the same as the --ignore-crashes option of frida-server.
+func (c *ControlServiceOptions) SetReportCrashes(report bool) {
+	C.frida_control_service_options_set_report_crashes(c.opts, goBoolToGBoolean(report))
+}
+
+// ReportCrashes returns whether the crashes are reported.
+func (c *ControlServiceOptions) ReportCrashes() bool {
+	return C.frida_control_service_options_get_report_crashes(c.opts) != 0
+}
+
+// Clean will clean the resources held by the control service options.
+func (c *ControlServiceOptions) Clean() {
+	clean(unsafe.Pointer(c.opts), unrefFrida)
+}
+
+// ControlServiceEventType represents the type of the ControlServiceEvent.
+type ControlServiceEventType int
+
+const (
+	ControlServiceStarted ControlServiceEventType = iota
+	ControlServiceStopped
+	ControlServiceError
+)
+
+func (c ControlServiceEventType) String() string {
+	return [...]string{"started",
+		"stopped",
+		"error"}[c]
+}
+
+// ControlServiceEvent is sent by ControlService.Events when the service changes the state.
+type ControlServiceEvent struct {
+	Type ControlServiceEventType
+	Err  error // populated for ControlServiceError
+}
+
+// ControlService exposes the local system over the endpoint in the same way frida-server does,
+// so that it can be embedded inside the Go program. Clients connect to it using
+// DeviceManager.AddRemoteDevice.
+type ControlService struct {
+	cs     *C.FridaControlService
+	params *EndpointParameters
+
+	state  serviceState
+	events eventHub[ControlServiceEvent]
+}
+
+// NewControlService creates new control service listening on the endpoint params,
+// opts can be nil to use the defaults.
+//
+// Example:
+//
+//	params, _ := frida.NewEndpointParameters(&frida.EParams{
+//		Address: "127.0.0.1",
+//		Port:    27042,
+//		Token:   "secret",
+//	})
+//	service := frida.NewControlService(params, nil)
+//	if err := service.Start(); err != nil {
+//		panic(err)
+//	}
+//	defer service.Clean()
+func NewControlService(params *EndpointParameters, opts *ControlServiceOptions) *ControlService {
+	var o *C.FridaControlServiceOptions = nil
+	if opts != nil {
+		o = opts.opts
+	}
+
+	cs := C.frida_control_service_new(params.params, o)
+	return &ControlService{
+		cs:     cs,
+		params: params,
+		state:  serviceState{name: "control service"},
+	}
+}
+
+// EndpointParams returns the endpoint parameters the service listens on.
+func (c *ControlService) EndpointParams() *EndpointParameters {
+	return c.params
+}
+
+// IsRunning returns whether the service is started.
+func (c *ControlService) IsRunning() bool {
+	return c.state.isRunning()
+}
+
+// StartWithContext runs Start but with context.
+// This function will properly handle cancelling the frida operation.
+func (c *ControlService) StartWithContext(ctx context.Context) error {
+	_, err := handleWithContext(ctx, func(cancel *Cancellable, doneC chan any, errC chan error) {
+		if err := c.Start(WithCancel(cancel)); err != nil {
+			errC <- err
+			return
+		}
+		doneC <- nil
+	})
+	return err
+}
+
+// Start starts listening for the clients.
+func (c *ControlService) Start(opts ...OptFunc) error {
+	o := setupOptions(opts)
+	return c.state.start(func() error {
+		var err *C.GError
+		C.frida_control_service_start_sync(c.cs, o.cancellable, &err)
+		return handleGError(err)
+	}, c.emit(ControlServiceStarted))
+}
+
+// StopWithContext runs Stop but with context.
+// This function will properly handle cancelling the frida operation.
+func (c *ControlService) StopWithContext(ctx context.Context) error {
+	_, err := handleWithContext(ctx, func(cancel *Cancellable, doneC chan any, errC chan error) {
+		if err := c.Stop(WithCancel(cancel)); err != nil {
+			errC <- err
+			return
+		}
+		doneC <- nil
+	})
+	return err
+}
+
+// Stop stops the service disconnecting all the clients.
+func (c *ControlService) Stop(opts ...OptFunc) error {
+	o := setupOptions(opts)
+	return c.state.stop(func() error {
+		var err *C.GError
+		C.frida_control_service_stop_sync(c.cs, o.cancellable, &err)
+		return handleGError(err)
+	}, c.emit(ControlServiceStopped))
+}
+
+// emit returns the func emitting typ, or the error event if the operation failed.
+func (c *ControlService) emit(typ ControlServiceEventType) func(err error) {
+	return func(err error) {
+		if err != nil {
+			c.events.emit(ControlServiceEvent{Type: ControlServiceError, Err: err})
+			return
+		}
+		c.events.emit(ControlServiceEvent{Type: typ})
+	}
+}
+
+// Events returns the channel receiving the service events until ctx is done.
+// Only the lifecycle of the service is reported, frida doesn't expose the clients
+// connecting to the service so there are no per-connection events.
+func (c *ControlService) Events(ctx context.Context) <-chan ControlServiceEvent {
+	return c.events.subscribe(ctx)
+}
+
+// Clean will clean the resources held by the control service.
+func (c *ControlService) Clean() {
+	c.events.close()
+	clean(unsafe.Pointer(c.cs), unrefFrida)
+}
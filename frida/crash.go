@@ -12,6 +12,16 @@ type Crash struct {
 	crash *C.FridaCrash
 }
 
+// CrashInfo holds the crash details as go values so it stays valid
+// after the underlying FridaCrash is released.
+type CrashInfo struct {
+	PID         int
+	ProcessName string
+	Summary     string
+	Report      string
+	Parameters  map[string]any
+}
+
 // PID returns the process identifier oc.crashed application
 func (c *Crash) PID() int {
 	if c.crash != nil {
@@ -54,6 +64,20 @@ func (c *Crash) Params() map[string]any {
 	return nil
 }
 
+// Info copies the crash details into CrashInfo; nil is returned if there is no crash.
+func (c *Crash) Info() *CrashInfo {
+	if c == nil || c.crash == nil {
+		return nil
+	}
+	return &CrashInfo{
+		PID:         c.PID(),
+		ProcessName: c.ProcessName(),
+		Summary:     c.Summary(),
+		Report:      c.Report(),
+		Parameters:  c.Params(),
+	}
+}
+
 // String returns string interpretation of the crash
 func (c *Crash) String() string {
 	if c.crash != nil {
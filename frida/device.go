@@ -510,14 +510,16 @@ func (d *Device) attach(val any, sessionOpts *SessionOptions, opts options) (*Se
 	}
 
 	var opt *C.FridaSessionOptions = nil
+	var persistTimeout uint
 	if sessionOpts != nil {
 		opt = sessionOpts.opts
+		persistTimeout = uint(sessionOpts.PersistTimeout())
 		defer clean(unsafe.Pointer(opt), unrefFrida)
 	}
 
 	var err *C.GError
 	s := C.frida_device_attach_sync(d.device, C.guint(pid), opt, opts.cancellable, &err)
-	return &Session{s}, handleGError(err)
+	return &Session{s: s, persistTimeout: persistTimeout}, handleGError(err)
 }
 
 // InjectLibraryFile will inject the library in the target with path to library specified.
@@ -3,6 +3,8 @@ package frida
 //#include "authentication-service.h"
 import "C"
 import (
+	"context"
+	"crypto/tls"
 	"errors"
 	"unsafe"
 )
@@ -13,19 +15,38 @@ import (
 // If the user is not authenticated, empty string should be returned.
 type AuthenticationFn func(string) string
 
+// Authenticate implements Authenticator, empty string returned by fn rejects
+// the token with ErrInvalidToken.
+func (fn AuthenticationFn) Authenticate(ctx context.Context, token string) (any, error) {
+	sessionInfo := fn(token)
+	if sessionInfo == "" {
+		return nil, ErrInvalidToken
+	}
+	return sessionInfo, nil
+}
+
 // EParams represent config needed to setup endpoint parameters that are used to setup Portal.
 // Types of authentication includes:
-//   - no authentication (not providing Token nor AuthenticationCallback)
+//   - no authentication (not providing Token, Authenticator nor AuthenticationCallback)
 //   - static authentication (providing token)
+//   - authentication using Authenticator
 //   - authentication using callback (providing AuthenticationCallback)
 //
-// If the Token and AuthenticationCallback are passed, static authentication will be used (token based)
+// If more of them are passed, the first one in the order above will be used.
+//
+// Certificate can be provided as Cert, as TLSCertificate, as PEM encoded CertificatePEM holding
+// both the certificate and the private key or as the path to such PEM file in Certificate; the
+// first one set is used.
 type EParams struct {
 	Address                string
 	Port                   uint16
 	Certificate            string
+	Cert                   *Certificate
+	CertificatePEM         []byte
+	TLSCertificate         *tls.Certificate
 	Origin                 string
 	Token                  string
+	Authenticator          Authenticator
 	AuthenticationCallback AuthenticationFn
 	AssetRoot              string
 }
@@ -35,16 +56,6 @@ type EndpointParameters struct {
 	params *C.FridaEndpointParameters
 }
 
-//export authenticate
-func authenticate(cb unsafe.Pointer, token *C.char) *C.char {
-	fn := (*func(string) string)(cb)
-	ret := (*fn)(C.GoString(token))
-	if ret == "" {
-		return nil
-	}
-	return C.CString(ret)
-}
-
 // NewEndpointParameters returns *EndpointParameters needed to setup Portal by using
 // provided EParams object.
 func NewEndpointParameters(params *EParams) (*EndpointParameters, error) {
@@ -65,8 +76,13 @@ func NewEndpointParameters(params *EParams) (*EndpointParameters, error) {
 		defer C.free(unsafe.Pointer(tknC))
 
 		authService = (*C.FridaAuthenticationService)(C.frida_static_authentication_service_new(tknC))
+	} else if params.Authenticator != nil {
+		authService = newAuthenticationService(params.Authenticator)
 	} else if params.AuthenticationCallback != nil {
-		authService = (*C.FridaAuthenticationService)(C.frida_go_authentication_service_new(unsafe.Pointer(&params.AuthenticationCallback)))
+		authService = newAuthenticationService(params.AuthenticationCallback)
+	}
+	if authService != nil {
+		defer clean(unsafe.Pointer(authService), unrefGObject)
 	}
 
 	if params.Origin != "" {
@@ -74,23 +90,28 @@ func NewEndpointParameters(params *EParams) (*EndpointParameters, error) {
 		defer C.free(unsafe.Pointer(originC))
 	}
 
-	var cert *C.GTlsCertificate = nil
-	if params.Certificate != "" {
-		crt, err := gTLSCertificateFromFile(params.Certificate)
-		if err != nil {
-			return nil, err
-		}
-		cert = crt.cert
+	cert, err := params.certificate()
+	if err != nil {
+		return nil, err
+	}
+	if cert != nil {
+		defer clean(unsafe.Pointer(cert.cert), unrefGObject)
 	}
 
 	if params.AssetRoot != "" {
 		assetPath = gFileFromPath(params.AssetRoot)
+		defer clean(unsafe.Pointer(assetPath), unrefGObject)
+	}
+
+	var gCert *C.GTlsCertificate = nil
+	if cert != nil {
+		gCert = cert.cert
 	}
 
 	ret := C.frida_endpoint_parameters_new(
 		addrC,
 		C.guint16(params.Port),
-		cert,
+		gCert,
 		originC,
 		authService,
 		assetPath,
@@ -99,6 +120,22 @@ func NewEndpointParameters(params *EParams) (*EndpointParameters, error) {
 	return &EndpointParameters{ret}, nil
 }
 
+func (e *EParams) certificate() (*Certificate, error) {
+	switch {
+	case e.Cert != nil:
+		// reference is dropped once passed to frida, keep the caller's one intact
+		C.g_object_ref(C.gpointer(e.Cert.cert))
+		return &Certificate{e.Cert.cert}, nil
+	case e.TLSCertificate != nil:
+		return CertificateFromTLS(e.TLSCertificate)
+	case len(e.CertificatePEM) > 0:
+		return gTLSCertificateFromPEM(e.CertificatePEM)
+	case e.Certificate != "":
+		return gTLSCertificateFromFile(e.Certificate)
+	}
+	return nil, nil
+}
+
 // Address returns the address of the endpoint parameters.
 func (e *EndpointParameters) Address() string {
 	return C.GoString(C.frida_endpoint_parameters_get_address(e.params))
@@ -109,9 +146,14 @@ func (e *EndpointParameters) Port() uint16 {
 	return uint16(C.frida_endpoint_parameters_get_port(e.params))
 }
 
-// Certificate returns the certificate of the endpoint parameters.
+// Certificate returns the certificate of the endpoint parameters, nil if none is set.
+// The certificate holds its own reference, so it should be cleaned with Clean.
 func (e *EndpointParameters) Certificate() *Certificate {
 	cert := C.frida_endpoint_parameters_get_certificate(e.params)
+	if cert == nil {
+		return nil
+	}
+	C.g_object_ref(C.gpointer(cert))
 	return &Certificate{cert}
 }
 
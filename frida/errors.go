@@ -3,5 +3,15 @@ package frida
 import "errors"
 
 var (
-	ErrContextCancelled = errors.New("context cancelled")
+	ErrContextCancelled    = errors.New("context cancelled")
+	ErrSessionClosed       = errors.New("session closed")
+	ErrScriptClosed        = errors.New("script closed")
+	ErrFleetClosed         = errors.New("fleet closed")
+	ErrRouterClosed        = errors.New("router closed")
+	ErrPackageOffline      = errors.New("package not available offline")
+	ErrPackageIntegrity    = errors.New("package integrity mismatch")
+	ErrPackageLocalOptions = errors.New("local package options require PackageManager.InstallWithContext")
+	ErrInvalidToken        = errors.New("invalid token")
+	ErrInvalidRelay        = errors.New("invalid relay")
+	ErrInvalidStunServer   = errors.New("invalid stun server")
 )
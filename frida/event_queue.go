@@ -0,0 +1,181 @@
+package frida
+
+import (
+	"context"
+	"sync"
+)
+
+// eventQueue forwards the events pushed from signal handlers to the channel.
+// Signal handlers run on the frida thread so push never blocks; events are
+// buffered until the consumer reads them.
+type eventQueue[T any] struct {
+	mu       sync.Mutex
+	queue    []T
+	limit    int
+	draining bool
+	notify   chan struct{}
+	done     chan struct{}
+	once     sync.Once
+	out      chan T
+}
+
+func newEventQueue[T any]() *eventQueue[T] {
+	q := &eventQueue[T]{
+		notify: make(chan struct{}, 1),
+		done:   make(chan struct{}),
+		out:    make(chan T),
+	}
+	go q.forward()
+	return q
+}
+
+// newBoundedEventQueue creates the queue keeping at most limit events waiting to be read,
+// the oldest event is dropped when the limit is reached.
+func newBoundedEventQueue[T any](limit int) *eventQueue[T] {
+	q := newEventQueue[T]()
+	q.limit = limit
+	return q
+}
+
+func (q *eventQueue[T]) push(ev T) {
+	q.mu.Lock()
+	if q.limit > 0 && len(q.queue) >= q.limit {
+		copy(q.queue, q.queue[1:])
+		q.queue = q.queue[:len(q.queue)-1]
+	}
+	q.queue = append(q.queue, ev)
+	q.mu.Unlock()
+
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+}
+
+func (q *eventQueue[T]) events() <-chan T {
+	return q.out
+}
+
+// close stops forwarding and closes the events channel, events not yet read are dropped.
+func (q *eventQueue[T]) close() {
+	q.once.Do(func() {
+		close(q.done)
+	})
+}
+
+// drain closes the events channel once all the events pushed so far are read.
+func (q *eventQueue[T]) drain() {
+	q.mu.Lock()
+	q.draining = true
+	q.mu.Unlock()
+
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+}
+
+func (q *eventQueue[T]) forward() {
+	defer close(q.out)
+
+	for {
+		q.mu.Lock()
+		queue := q.queue
+		draining := q.draining
+		q.queue = nil
+		q.mu.Unlock()
+
+		for _, ev := range queue {
+			select {
+			case q.out <- ev:
+			case <-q.done:
+				return
+			}
+		}
+
+		if draining {
+			return
+		}
+
+		select {
+		case <-q.notify:
+		case <-q.done:
+			return
+		}
+	}
+}
+
+// eventHub sends the emitted events to all the subscribers, the zero value is ready to use.
+type eventHub[T any] struct {
+	mu          sync.Mutex
+	subscribers map[*eventQueue[T]]struct{}
+	closed      bool
+	done        chan struct{}
+}
+
+// doneC returns the channel closed when the hub is closed, h.mu must be held.
+func (h *eventHub[T]) doneC() chan struct{} {
+	if h.done == nil {
+		h.done = make(chan struct{})
+	}
+	return h.done
+}
+
+// subscribe returns the channel receiving the events until ctx is done or the hub is closed.
+// The channel returned after the hub is closed is already closed.
+func (h *eventHub[T]) subscribe(ctx context.Context) <-chan T {
+	h.mu.Lock()
+	if h.closed {
+		h.mu.Unlock()
+		ch := make(chan T)
+		close(ch)
+		return ch
+	}
+
+	q := newEventQueue[T]()
+	if h.subscribers == nil {
+		h.subscribers = make(map[*eventQueue[T]]struct{})
+	}
+	h.subscribers[q] = struct{}{}
+	done := h.doneC()
+	h.mu.Unlock()
+
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-done:
+		}
+		h.mu.Lock()
+		delete(h.subscribers, q)
+		h.mu.Unlock()
+		q.close()
+	}()
+
+	return q.events()
+}
+
+func (h *eventHub[T]) emit(ev T) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for q := range h.subscribers {
+		q.push(ev)
+	}
+}
+
+// close closes the channels of all the subscribers, later subscribers get a closed channel.
+func (h *eventHub[T]) close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return
+	}
+	h.closed = true
+	close(h.doneC())
+
+	for q := range h.subscribers {
+		q.close()
+		delete(h.subscribers, q)
+	}
+}
@@ -0,0 +1,436 @@
+package frida
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	defaultFleetWorkers       = 8
+	defaultFleetMessageBuffer = 1024
+)
+
+// FleetOptions configures how the fleet attaches to the processes and which script gets loaded.
+type FleetOptions struct {
+	// Script is the source of the agent loaded into every process.
+	Script string
+	// ScriptName is the name of the script, defaults to "frida-go".
+	ScriptName string
+	// Snapshot is optional snapshot created with Session.SnapshotScript shared by all the scripts.
+	Snapshot []byte
+	// Realm and PersistTimeout are used to create SessionOptions for each attach.
+	Realm          Realm
+	PersistTimeout uint
+	// Workers is the maximum number of processes being attached to or called concurrently.
+	Workers int
+	// MessageBuffer is the maximum number of messages waiting to be read from Fleet.Messages,
+	// the oldest messages are dropped once it is reached. Defaults to 1024, negative disables the
+	// message collection.
+	MessageBuffer int
+}
+
+// FleetMessage is the message received from the script loaded in the process with PID.
+type FleetMessage struct {
+	PID     int
+	Message string
+	Data    []byte
+}
+
+// FleetResult holds the outcome of the operation for the process with PID.
+type FleetResult struct {
+	PID   int
+	Value any
+	Err   error
+}
+
+// FleetError is returned by AttachFleet when some of the processes could not be attached to.
+// The fleet is still returned containing the processes that succeeded. Processes not attached to
+// because ctx was done hold the ctx error.
+type FleetError struct {
+	Errors map[int]error
+}
+
+func (f *FleetError) Error() string {
+	pids := make([]int, 0, len(f.Errors))
+	for pid := range f.Errors {
+		pids = append(pids, pid)
+	}
+	sort.Ints(pids)
+
+	msgs := make([]string, len(pids))
+	for i, pid := range pids {
+		msgs[i] = fmt.Sprintf("pid %d: %v", pid, f.Errors[pid])
+	}
+	return fmt.Sprintf("failed to attach to %d process(es): %s", len(pids), strings.Join(msgs, "; "))
+}
+
+type fleetMember struct {
+	pid     int
+	session *Session
+	script  *Script
+}
+
+// Fleet represents the same script loaded into multiple processes on the device.
+type Fleet struct {
+	device    DeviceInt
+	opts      FleetOptions
+	mu        sync.Mutex
+	members   map[int]*fleetMember
+	attaching map[int]struct{}
+	closed    bool
+
+	messages *eventQueue[FleetMessage]
+}
+
+// PIDsMatching returns the PIDs of the processes on the device whose name matches the pattern.
+func PIDsMatching(device DeviceInt, pattern *regexp.Regexp) ([]int, error) {
+	procs, err := device.EnumerateProcesses(ScopeMinimal)
+	if err != nil {
+		return nil, err
+	}
+
+	var pids []int
+	for _, proc := range procs {
+		if pattern.MatchString(proc.Name()) {
+			pids = append(pids, proc.PID())
+		}
+		proc.Clean()
+	}
+	return pids, nil
+}
+
+// AttachFleet attaches to all the pids on the device concurrently, creates and loads the script
+// from opts in each of them and merges their messages into Fleet.Messages. Duplicate pids are attached to once.
+//
+// Example:
+//
+//	pids, _ := frida.PIDsMatching(device, regexp.MustCompile("^com\\.example"))
+//	fleet, err := frida.AttachFleet(ctx, device, pids, &frida.FleetOptions{Script: agent})
+//	if err != nil {
+//		// *frida.FleetError holds the processes that failed, the rest are attached
+//	}
+//	defer fleet.Close(ctx)
+//
+//	for _, res := range fleet.Call(ctx, "version") {
+//		fmt.Println(res.PID, res.Value, res.Err)
+//	}
+func AttachFleet(ctx context.Context, device DeviceInt, pids []int, opts *FleetOptions) (*Fleet, error) {
+	if d, ok := device.(*Device); device == nil || ok && (d == nil || d.device == nil) {
+		return nil, errors.New("could not attach fleet for nil device")
+	}
+	if opts == nil || opts.Script == "" {
+		return nil, errors.New("you need to provide script for the fleet")
+	}
+
+	o := *opts
+	if o.Workers <= 0 {
+		o.Workers = defaultFleetWorkers
+	}
+	if o.ScriptName == "" {
+		o.ScriptName = "frida-go"
+	}
+	if o.MessageBuffer == 0 {
+		o.MessageBuffer = defaultFleetMessageBuffer
+	}
+
+	f := &Fleet{
+		device:    device,
+		opts:      o,
+		members:   make(map[int]*fleetMember),
+		attaching: make(map[int]struct{}),
+		messages:  newBoundedEventQueue[FleetMessage](o.MessageBuffer),
+	}
+
+	seen := make(map[int]struct{}, len(pids))
+	unique := make([]int, 0, len(pids))
+	for _, pid := range pids {
+		if _, ok := seen[pid]; !ok {
+			seen[pid] = struct{}{}
+			unique = append(unique, pid)
+		}
+	}
+
+	errs := make(map[int]error)
+	var errsMu sync.Mutex
+
+	started := f.forEach(ctx, len(unique), func(i int) {
+		pid := unique[i]
+		if err := f.attach(ctx, pid); err != nil {
+			errsMu.Lock()
+			errs[pid] = err
+			errsMu.Unlock()
+		}
+	})
+	for _, pid := range unique[started:] {
+		errs[pid] = ctx.Err()
+	}
+
+	if len(errs) > 0 {
+		return f, &FleetError{Errors: errs}
+	}
+	return f, nil
+}
+
+// PIDs returns the PIDs of the processes in the fleet.
+func (f *Fleet) PIDs() []int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	pids := make([]int, 0, len(f.members))
+	for pid := range f.members {
+		pids = append(pids, pid)
+	}
+	sort.Ints(pids)
+	return pids
+}
+
+// Session returns the session for the process with pid or nil if pid is not in the fleet.
+func (f *Fleet) Session(pid int) *Session {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if m, ok := f.members[pid]; ok {
+		return m.session
+	}
+	return nil
+}
+
+// Script returns the script loaded into the process with pid or nil if pid is not in the fleet.
+func (f *Fleet) Script(pid int) *Script {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if m, ok := f.members[pid]; ok {
+		return m.script
+	}
+	return nil
+}
+
+// Messages returns the channel with the messages from all the scripts in the fleet.
+// The channel is closed once the fleet is closed. At most FleetOptions.MessageBuffer messages are
+// kept until read, and no messages are delivered if the collection is disabled.
+func (f *Fleet) Messages() <-chan FleetMessage {
+	return f.messages.events()
+}
+
+// Add attaches to the process with pid and loads the fleet script into it.
+// ErrFleetClosed is returned once the fleet is closed, and an error if pid is already in the fleet.
+func (f *Fleet) Add(ctx context.Context, pid int) error {
+	return f.attach(ctx, pid)
+}
+
+// reserve marks pid as being attached to, so that the same process is never attached to twice.
+func (f *Fleet) reserve(pid int) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return ErrFleetClosed
+	}
+	if _, ok := f.members[pid]; ok {
+		return fmt.Errorf("pid %d is already in the fleet", pid)
+	}
+	if _, ok := f.attaching[pid]; ok {
+		return fmt.Errorf("pid %d is already being attached to", pid)
+	}
+	f.attaching[pid] = struct{}{}
+	return nil
+}
+
+// Remove closes the session with the process with pid and removes it from the fleet.
+func (f *Fleet) Remove(ctx context.Context, pid int) error {
+	f.mu.Lock()
+	m, ok := f.members[pid]
+	delete(f.members, pid)
+	f.mu.Unlock()
+
+	if !ok {
+		return fmt.Errorf("pid %d is not in the fleet", pid)
+	}
+	return m.session.Close(ctx)
+}
+
+// Call calls fn from the rpc.exports of every script in the fleet with args provided
+// and collects per-process results.
+func (f *Fleet) Call(ctx context.Context, fn string, args ...any) []FleetResult {
+	f.mu.Lock()
+	members := make([]*fleetMember, 0, len(f.members))
+	for _, m := range f.members {
+		members = append(members, m)
+	}
+	f.mu.Unlock()
+
+	// results of the calls that were not started before ctx was done stay cancelled
+	results := make([]FleetResult, len(members))
+	for i, m := range members {
+		results[i] = FleetResult{PID: m.pid, Err: ErrContextCancelled}
+	}
+
+	f.forEach(ctx, len(members), func(i int) {
+		ret := members[i].script.ExportsCallWithContext(ctx, fn, args...)
+		if err, ok := ret.(error); ok {
+			results[i].Err = err
+		} else {
+			results[i].Value = ret
+			results[i].Err = nil
+		}
+	})
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].PID < results[j].PID
+	})
+	return results
+}
+
+// Post posts the message to every script in the fleet.
+func (f *Fleet) Post(jsonString string, data []byte) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, m := range f.members {
+		m.script.Post(jsonString, data)
+	}
+}
+
+// Close closes all the sessions in the fleet and closes the Messages channel.
+func (f *Fleet) Close(ctx context.Context) error {
+	f.mu.Lock()
+	members := f.members
+	f.members = make(map[int]*fleetMember)
+	f.closed = true
+	f.mu.Unlock()
+
+	var firstErr error
+	for _, m := range members {
+		if err := m.session.Close(ctx); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	f.messages.close()
+	return firstErr
+}
+
+func (f *Fleet) attach(ctx context.Context, pid int) error {
+	if err := f.reserve(pid); err != nil {
+		return err
+	}
+	defer func() {
+		f.mu.Lock()
+		delete(f.attaching, pid)
+		f.mu.Unlock()
+	}()
+
+	var sessionOpts *SessionOptions
+	if f.opts.PersistTimeout > 0 || f.opts.Realm != RealmNative {
+		sessionOpts = NewSessionOptions(f.opts.Realm, f.opts.PersistTimeout)
+	}
+
+	session, err := f.device.AttachWithContext(ctx, pid, sessionOpts)
+	if err != nil {
+		return err
+	}
+
+	scriptOpts := NewScriptOptions(f.opts.ScriptName)
+	defer scriptOpts.Clean()
+	if len(f.opts.Snapshot) > 0 {
+		scriptOpts.SetSnapshot(f.opts.Snapshot)
+	}
+
+	script, err := session.CreateScriptWithOptions(f.opts.Script, scriptOpts)
+	if err != nil {
+		session.Close(ctx)
+		return err
+	}
+
+	if f.opts.MessageBuffer > 0 {
+		script.On("message", func(message string, data []byte) {
+			f.messages.push(FleetMessage{
+				PID:     pid,
+				Message: message,
+				Data:    data,
+			})
+		})
+	}
+
+	// connected before Load so that the detach while loading is not missed
+	lost := make(chan struct{})
+	var lostOnce sync.Once
+	session.OnDetached(func(ev DetachEvent) {
+		if ev.Reason.IsRecoverable() {
+			return
+		}
+		lostOnce.Do(func() { close(lost) })
+
+		f.mu.Lock()
+		m, ok := f.members[pid]
+		if ok && m.session == session {
+			delete(f.members, pid)
+		}
+		f.mu.Unlock()
+
+		if ok {
+			// handler runs on the frida thread, Close blocks on it
+			go session.Close(context.Background())
+		}
+	})
+
+	if err := script.Load(); err != nil {
+		session.Close(ctx)
+		return err
+	}
+
+	f.mu.Lock()
+	select {
+	case <-lost:
+		f.mu.Unlock()
+		session.Close(ctx)
+		return fmt.Errorf("pid %d detached while loading the script", pid)
+	default:
+	}
+	if f.closed {
+		f.mu.Unlock()
+		session.Close(ctx)
+		return ErrFleetClosed
+	}
+	f.members[pid] = &fleetMember{
+		pid:     pid,
+		session: session,
+		script:  script,
+	}
+	f.mu.Unlock()
+
+	return nil
+}
+
+// forEach runs fn for indexes up to n using at most opts.Workers goroutines and returns the number
+// of indexes fn was started for; it stops starting new ones once ctx is done.
+func (f *Fleet) forEach(ctx context.Context, n int, fn func(i int)) int {
+	sem := make(chan struct{}, f.opts.Workers)
+	var wg sync.WaitGroup
+
+	for i := 0; i < n; i++ {
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return i
+		case sem <- struct{}{}:
+		}
+
+		wg.Add(1)
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			fn(i)
+		}(i)
+	}
+	wg.Wait()
+	return n
+}
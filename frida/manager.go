@@ -10,6 +10,7 @@ static FridaDeviceManagerPredicate manager_predicate() {
 import "C"
 
 import (
+	"sync"
 	"unsafe"
 )
 
@@ -50,12 +51,16 @@ type DeviceManagerInt interface {
 // Single instance of the DeviceManager is created when you call frida.Attach() or frida.LocalDevice().
 type DeviceManager struct {
 	manager *C.FridaDeviceManager
+
+	mu      sync.Mutex
+	remotes map[string]*remoteEntry
+	events  eventHub[RemoteDeviceEvent]
 }
 
 // NewDeviceManager returns new frida device manager.
 func NewDeviceManager() *DeviceManager {
 	manager := C.frida_device_manager_new()
-	return &DeviceManager{manager}
+	return &DeviceManager{manager: manager}
 }
 
 // Close method will close current manager.
@@ -170,15 +175,37 @@ func (d *DeviceManager) FindDeviceByType(devType DeviceType) (DeviceInt, error)
 	return &Device{device: device}, handleGError(err)
 }
 
-// AddRemoteDevice add a remote device from the provided address with remoteOpts populated
+// AddRemoteDevice add a remote device from the provided address with remoteOpts populated,
+// remoteOpts can be nil to use the defaults. The device is kept in the remote registry,
+// see RemoteDevices.
 func (d *DeviceManager) AddRemoteDevice(address string, remoteOpts *RemoteDeviceOptions) (DeviceInt, error) {
+	device, err := d.addRemoteDevice(address, remoteOpts)
+	if err != nil {
+		return device, err
+	}
+
+	d.emitRemote(RemoteDeviceAdded, address, device)
+	return device, nil
+}
+
+func (d *DeviceManager) addRemoteDevice(address string, remoteOpts *RemoteDeviceOptions) (*Device, error) {
 	addressC := C.CString(address)
 	defer C.free(unsafe.Pointer(addressC))
 
+	var opts *C.FridaRemoteDeviceOptions = nil
+	if remoteOpts != nil {
+		opts = remoteOpts.opts
+	}
+
 	var err *C.GError
-	device := C.frida_device_manager_add_remote_device_sync(d.manager, addressC, remoteOpts.opts, nil, &err)
+	device := C.frida_device_manager_add_remote_device_sync(d.manager, addressC, opts, nil, &err)
+	if gErr := handleGError(err); gErr != nil {
+		return &Device{device: device}, gErr
+	}
 
-	return &Device{device: device}, handleGError(err)
+	dev := &Device{device: device}
+	d.register(address, dev, remoteOpts)
+	return dev, nil
 }
 
 // RemoveRemoteDevice removes remote device available at address
@@ -191,11 +218,30 @@ func (d *DeviceManager) RemoveRemoteDevice(address string) error {
 		addressC,
 		nil,
 		&err)
-	return handleGError(err)
+	// the lost device is no longer known to frida, so it is dropped from the registry regardless
+	if gErr := handleGError(err); gErr != nil && !d.isLost(address) {
+		return gErr
+	}
+
+	entry := d.unregister(address)
+	if entry != nil {
+		d.emitRemote(RemoteDeviceRemoved, address, entry.device)
+		entry.release()
+	}
+	return nil
 }
 
 // Clean will clean the resources held by the manager.
 func (d *DeviceManager) Clean() {
+	d.mu.Lock()
+	remotes := d.remotes
+	d.remotes = nil
+	d.mu.Unlock()
+
+	for _, entry := range remotes {
+		entry.release()
+	}
+	d.events.close()
 	clean(unsafe.Pointer(d.manager), unrefFrida)
 }
 
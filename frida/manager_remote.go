@@ -0,0 +1,233 @@
+package frida
+
+//#include <frida-core.h>
+import "C"
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sort"
+	"unsafe"
+)
+
+// RemoteDeviceEventType represents the type of the RemoteDeviceEvent.
+type RemoteDeviceEventType int
+
+const (
+	RemoteDeviceAdded RemoteDeviceEventType = iota
+	RemoteDeviceUpdated
+	RemoteDeviceRemoved
+	RemoteDeviceLost
+)
+
+func (r RemoteDeviceEventType) String() string {
+	return [...]string{"added",
+		"updated",
+		"removed",
+		"lost"}[r]
+}
+
+// RemoteDeviceEvent is sent by DeviceManager.RemoteEvents when the remote device added
+// by the address changes. Device holds its own reference which is released once it is
+// garbage collected, so it must not be cleaned.
+type RemoteDeviceEvent struct {
+	Type    RemoteDeviceEventType
+	Address string
+	Device  DeviceInt
+}
+
+// RemoteDevice is the remote device added with DeviceManager.AddRemoteDevice.
+// Device holds its own reference, Clean it once done.
+type RemoteDevice struct {
+	Address           string
+	Device            DeviceInt
+	Origin            string
+	Token             string
+	KeepAliveInterval int
+	Lost              bool
+}
+
+type remoteEntry struct {
+	device  *Device
+	opts    *RemoteDeviceOptions
+	handler C.gulong
+	lost    bool
+}
+
+// register adds the device to the remote registry, replacing the device previously added
+// at the same address.
+func (d *DeviceManager) register(address string, device *Device, opts *RemoteDeviceOptions) {
+	entry := &remoteEntry{
+		device: retainDevice(device),
+		opts:   opts.copy(),
+	}
+
+	// connected before the entry is published so that release always sees the handler;
+	// the handler ignores the signal until then
+	entry.handler = connectClosure(unsafe.Pointer(entry.device.device), "lost", func() {
+		d.mu.Lock()
+		if d.remotes[address] != entry {
+			d.mu.Unlock()
+			return
+		}
+		entry.lost = true
+		d.mu.Unlock()
+
+		d.emitRemote(RemoteDeviceLost, address, entry.device)
+	})
+
+	d.mu.Lock()
+	if d.remotes == nil {
+		d.remotes = make(map[string]*remoteEntry)
+	}
+	old := d.remotes[address]
+	d.remotes[address] = entry
+	d.mu.Unlock()
+
+	if old != nil {
+		old.release()
+	}
+}
+
+// retainDevice returns the new *Device holding its own reference on the device.
+func retainDevice(device *Device) *Device {
+	C.g_object_ref(C.gpointer(device.device))
+	return &Device{device.device}
+}
+
+// emitRemote sends the event with its own reference on the device, released once the
+// device is garbage collected, as the subscribers may outlive the registry entry.
+func (d *DeviceManager) emitRemote(typ RemoteDeviceEventType, address string, device *Device) {
+	retained := retainDevice(device)
+	runtime.SetFinalizer(retained, func(dev *Device) {
+		dev.Clean()
+	})
+	d.events.emit(RemoteDeviceEvent{Type: typ, Address: address, Device: retained})
+}
+
+// unregister removes the device from the remote registry.
+func (d *DeviceManager) unregister(address string) *remoteEntry {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	entry := d.remotes[address]
+	delete(d.remotes, address)
+	return entry
+}
+
+// isLost returns whether the device added at the address was lost.
+func (d *DeviceManager) isLost(address string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	entry, ok := d.remotes[address]
+	return ok && entry.lost
+}
+
+func (r *remoteEntry) release() {
+	if r.handler != 0 {
+		disconnectClosure(unsafe.Pointer(r.device.device), r.handler)
+	}
+	r.device.Clean()
+	r.opts.Clean()
+}
+
+// RemoteDevices returns the remote devices added by the address, sorted by the address.
+// Lost devices are kept until they are removed with RemoveRemoteDevice or updated.
+// Every returned device holds its own reference, so the caller has to Clean them.
+func (d *DeviceManager) RemoteDevices() []RemoteDevice {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	devices := make([]RemoteDevice, 0, len(d.remotes))
+	for address, entry := range d.remotes {
+		devices = append(devices, RemoteDevice{
+			Address:           address,
+			Device:            retainDevice(entry.device),
+			Origin:            entry.opts.Origin(),
+			Token:             entry.opts.Token(),
+			KeepAliveInterval: entry.opts.KeepAliveInterval(),
+			Lost:              entry.lost,
+		})
+	}
+	sort.Slice(devices, func(i, j int) bool {
+		return devices[i].Address < devices[j].Address
+	})
+	return devices
+}
+
+// UpdateRemoteDevice changes the options of the remote device added at the address. fn is called
+// with the copy of the current options, e.g. to change the token or the keepalive interval.
+// Frida can't change the options of the connected device, so when the options change the device is
+// removed and added again with them, which ends the sessions attached through the old device.
+// If nothing changed the current device is returned as is, unless it was lost in which case it is added again.
+// If the device can't be added with the new options, it is added back with the previous ones and the
+// error is returned; if that fails as well the device is removed from the registry.
+// The returned device holds its own reference, Clean it once done.
+//
+// Example:
+//
+//	dev, err := mgr.UpdateRemoteDevice("10.0.0.5:27042", func(opts *frida.RemoteDeviceOptions) {
+//		opts.SetToken("new-token")
+//		opts.SetKeepAlive(10)
+//	})
+func (d *DeviceManager) UpdateRemoteDevice(address string, fn func(opts *RemoteDeviceOptions)) (DeviceInt, error) {
+	d.mu.Lock()
+	entry, ok := d.remotes[address]
+	var opts, prev *RemoteDeviceOptions
+	var device *Device
+	lost := false
+	if ok {
+		opts = entry.opts.copy()
+		prev = entry.opts.copy()
+		device = retainDevice(entry.device)
+		lost = entry.lost
+	}
+	d.mu.Unlock()
+
+	if !ok {
+		return nil, fmt.Errorf("no remote device added at %s", address)
+	}
+	defer opts.Clean()
+	defer prev.Clean()
+
+	fn(opts)
+
+	if !lost && opts.equal(prev) {
+		return device, nil
+	}
+	device.Clean()
+
+	addressC := C.CString(address)
+	defer C.free(unsafe.Pointer(addressC))
+
+	var gErr *C.GError
+	C.frida_device_manager_remove_remote_device_sync(d.manager, addressC, nil, &gErr)
+	// the lost device is no longer known to frida, so the error is only returned for the live one
+	if err := handleGError(gErr); err != nil && !lost {
+		return nil, err
+	}
+
+	newDevice, err := d.addRemoteDevice(address, opts)
+	if err != nil {
+		restored, rbErr := d.addRemoteDevice(address, prev)
+		if rbErr != nil {
+			if old := d.unregister(address); old != nil {
+				d.emitRemote(RemoteDeviceRemoved, address, old.device)
+				old.release()
+			}
+			return nil, err
+		}
+		restored.Clean()
+		return nil, err
+	}
+
+	d.emitRemote(RemoteDeviceUpdated, address, newDevice)
+	return newDevice, nil
+}
+
+// RemoteEvents returns the channel receiving the events of the remote devices added by the
+// address until ctx is done.
+func (d *DeviceManager) RemoteEvents(ctx context.Context) <-chan RemoteDeviceEvent {
+	return d.events.subscribe(ctx)
+}
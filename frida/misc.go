@@ -69,6 +69,13 @@ func handleGError(gErr *C.GError) error {
 	return fmt.Errorf("FError: %s", C.GoString(gErr.message))
 }
 
+func goBoolToGBoolean(v bool) C.gboolean {
+	if v {
+		return C.gboolean(1)
+	}
+	return C.gboolean(0)
+}
+
 // MessageType represents all possible message types populated
 // in the first argument of the on_message callback.
 type MessageType string
@@ -2,7 +2,12 @@ package frida
 
 //#include <frida-core.h>
 import "C"
-import "unsafe"
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unsafe"
+)
 
 type PackageManager struct {
 	p *C.FridaPackageManager
@@ -13,12 +18,101 @@ func NewPackageManager() *PackageManager {
 	return &PackageManager{p}
 }
 
-func (p *PackageManager) Install(options *PackageInstallOptions) (*PackageInstallResult, error) {
+// PackageInfo is the Go copy of the Package.
+type PackageInfo struct {
+	Name        string
+	Version     string
+	Description string
+	URL         string
+}
+
+// PackageInstallProgress is passed to the callback of InstallWithProgress.
+// Fraction is between 0 and 1, Details is empty for phases without details.
+type PackageInstallProgress struct {
+	Phase    PackageInstallPhase
+	Fraction float64
+	Details  string
+}
+
+// Install installs the packages specified in options.
+// Local sources, lockfile and offline mode are only honored by InstallWithContext
+// and InstallWithProgress, ErrPackageLocalOptions is returned if any of them is set.
+func (p *PackageManager) Install(installOpts *PackageInstallOptions, opts ...OptFunc) (*PackageInstallResult, error) {
+	if installOpts.hasLocalOptions() {
+		return nil, ErrPackageLocalOptions
+	}
+	o := setupOptions(opts)
+	return p.install(installOpts, o)
+}
+
+func (p *PackageManager) install(installOpts *PackageInstallOptions, opts options) (*PackageInstallResult, error) {
+	if installOpts == nil {
+		installOpts = NewPackageInstallOptions()
+		defer installOpts.Clean()
+	}
+
 	var err *C.GError
-	rt := C.frida_package_manager_install_sync(p.p, options.p, nil, &err)
+	rt := C.frida_package_manager_install_sync(p.p, installOpts.p, opts.cancellable, &err)
 	return &PackageInstallResult{rt}, handleGError(err)
 }
 
+// InstallWithContext runs Install but with context and returns the installed packages.
+// This function will properly handle cancelling the frida operation.
+// Local sources are installed first and the remaining specs are installed by frida;
+// in offline mode ErrPackageOffline is returned instead of reaching the registry.
+func (p *PackageManager) InstallWithContext(ctx context.Context, installOpts *PackageInstallOptions) ([]PackageInfo, error) {
+	if !installOpts.hasLocalOptions() {
+		return p.installWithContext(ctx, installOpts)
+	}
+	return p.installLocal(ctx, installOpts)
+}
+
+func (p *PackageManager) installWithContext(ctx context.Context, installOpts *PackageInstallOptions) ([]PackageInfo, error) {
+	rawPkgs, err := handleWithContext(ctx, func(c *Cancellable, doneC chan any, errC chan error) {
+		res, err := p.install(installOpts, options{cancellable: c.cancellable})
+		if err != nil {
+			errC <- err
+			return
+		}
+		pkgs := res.Packages()
+		res.Clean()
+		doneC <- pkgs
+	})
+	pkgs, _ := rawPkgs.([]PackageInfo)
+	return pkgs, err
+}
+
+// InstallWithProgress runs InstallWithContext calling fn for each "install-progress" signal.
+// fn is called from separate goroutine in the order the signals were emitted and all the calls
+// are done by the time InstallWithProgress returns.
+func (p *PackageManager) InstallWithProgress(ctx context.Context, installOpts *PackageInstallOptions, fn func(PackageInstallProgress)) ([]PackageInfo, error) {
+	q := newEventQueue[PackageInstallProgress]()
+	id := connectClosure(unsafe.Pointer(p.p), "install-progress", func(phase PackageInstallPhase, fraction float64, details string) {
+		q.push(PackageInstallProgress{
+			Phase:    phase,
+			Fraction: fraction,
+			Details:  details,
+		})
+	})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for ev := range q.events() {
+			if fn != nil {
+				fn(ev)
+			}
+		}
+	}()
+
+	pkgs, err := p.InstallWithContext(ctx, installOpts)
+	disconnectClosure(unsafe.Pointer(p.p), id)
+	q.drain()
+	<-done
+
+	return pkgs, err
+}
+
 func (p *PackageManager) GetRegistry() string {
 	rt := C.frida_package_manager_get_registry(p.p)
 	return C.GoString(rt)
@@ -31,17 +125,41 @@ func (p *PackageManager) SetRegistry(value string) {
 	C.frida_package_manager_set_registry(p.p, valueC)
 }
 
-func (p *PackageManager) Search(query string, options *PackageSearchOptions) (*PackageSearchResult, error) {
+// Search searches the registry for the packages matching the query.
+func (p *PackageManager) Search(query string, searchOpts *PackageSearchOptions, opts ...OptFunc) (*PackageSearchResult, error) {
+	o := setupOptions(opts)
+	return p.search(query, searchOpts, o)
+}
+
+// SearchWithContext runs Search but with context and returns the packages found.
+// This function will properly handle cancelling the frida operation.
+func (p *PackageManager) SearchWithContext(ctx context.Context, query string, searchOpts *PackageSearchOptions) ([]PackageInfo, error) {
+	rawPkgs, err := handleWithContext(ctx, func(c *Cancellable, doneC chan any, errC chan error) {
+		res, err := p.Search(query, searchOpts, WithCancel(c))
+		if err != nil {
+			errC <- err
+			return
+		}
+		pkgs := res.Packages()
+		res.Clean()
+		doneC <- pkgs
+	})
+	pkgs, _ := rawPkgs.([]PackageInfo)
+	return pkgs, err
+}
+
+func (p *PackageManager) search(query string, searchOpts *PackageSearchOptions, opts options) (*PackageSearchResult, error) {
 	queryC := C.CString(query)
 	defer C.free(unsafe.Pointer(queryC))
 
-	psearchOpts := options
+	psearchOpts := searchOpts
 	if psearchOpts == nil {
 		psearchOpts = NewPackageSearchOptions()
+		defer psearchOpts.Clean()
 	}
 
 	var err *C.GError
-	rt := C.frida_package_manager_search_sync(p.p, queryC, psearchOpts.p, nil, &err)
+	rt := C.frida_package_manager_search_sync(p.p, queryC, psearchOpts.p, opts.cancellable, &err)
 	return &PackageSearchResult{rt}, handleGError(err)
 }
 
@@ -83,6 +201,26 @@ func (p *PackageList) Get(index int) *Package {
 	return &Package{rt}
 }
 
+// Packages returns the packages of the list as Go slice.
+func (p *PackageList) Packages() []PackageInfo {
+	if p == nil || p.p == nil {
+		return nil
+	}
+
+	pkgs := make([]PackageInfo, p.Size())
+	for i := range pkgs {
+		pkg := p.Get(i)
+		pkgs[i] = PackageInfo{
+			Name:        pkg.GetName(),
+			Version:     pkg.GetVersion(),
+			Description: pkg.GetDescription(),
+			URL:         pkg.GetURL(),
+		}
+		clean(unsafe.Pointer(pkg.p), unrefFrida)
+	}
+	return pkgs
+}
+
 type PackageSearchOptions struct {
 	p *C.FridaPackageSearchOptions
 }
@@ -114,6 +252,11 @@ func (p *PackageSearchOptions) GetOffset() uint {
 	return uint(rt)
 }
 
+// Clean will clean the resources held by the options.
+func (p *PackageSearchOptions) Clean() {
+	clean(unsafe.Pointer(p.p), unrefFrida)
+}
+
 type PackageSearchResult struct {
 	p *C.FridaPackageSearchResult
 }
@@ -123,13 +266,72 @@ func (p *PackageSearchResult) GetPackages() *PackageList {
 	return &PackageList{rt}
 }
 
+// Packages returns the packages found as Go slice.
+func (p *PackageSearchResult) Packages() []PackageInfo {
+	return p.GetPackages().Packages()
+}
+
 func (p *PackageSearchResult) GetTotal() uint {
 	rt := C.frida_package_search_result_get_total(p.p)
 	return uint(rt)
 }
 
+// Clean will clean the resources held by the search result.
+func (p *PackageSearchResult) Clean() {
+	clean(unsafe.Pointer(p.p), unrefFrida)
+}
+
+// PackageSpec is the parsed package spec such as "frida-java-bridge@^6.0.0".
+type PackageSpec struct {
+	Name    string
+	Version string
+}
+
+// ParsePackageSpec parses the spec in the name[@version] format, scoped names
+// such as "@types/frida-gum@18" are supported.
+func ParsePackageSpec(spec string) (PackageSpec, error) {
+	name, version := spec, ""
+	if at := strings.LastIndex(spec, "@"); at > 0 {
+		name, version = spec[:at], spec[at+1:]
+		if version == "" {
+			return PackageSpec{}, fmt.Errorf("invalid package spec %q: empty version", spec)
+		}
+	}
+
+	if name == "" {
+		return PackageSpec{}, fmt.Errorf("invalid package spec %q: empty name", spec)
+	}
+	if strings.HasPrefix(name, "@") {
+		scope, pkg, ok := strings.Cut(name[1:], "/")
+		if !ok || scope == "" || pkg == "" {
+			return PackageSpec{}, fmt.Errorf("invalid package spec %q: invalid scoped name", spec)
+		}
+	}
+	return PackageSpec{Name: name, Version: version}, nil
+}
+
+// String returns the spec in the name[@version] format.
+func (p PackageSpec) String() string {
+	if p.Version == "" {
+		return p.Name
+	}
+	return p.Name + "@" + p.Version
+}
+
 type PackageInstallOptions struct {
-	p *C.FridaPackageInstallOptions
+	p            *C.FridaPackageInstallOptions
+	specs        []string
+	role         PackageRole
+	omits        []PackageRole
+	localSources []localPackageSource
+	lockfile     string
+	offline      bool
+}
+
+// NewPackageInstallOptions creates new package install options.
+func NewPackageInstallOptions() *PackageInstallOptions {
+	p := C.frida_package_install_options_new()
+	return &PackageInstallOptions{p: p}
 }
 
 func (p *PackageInstallOptions) GetProjectRoot() string {
@@ -144,8 +346,47 @@ func (p *PackageInstallOptions) SetProjectRoot(value string) {
 	C.frida_package_install_options_set_project_root(p.p, valueC)
 }
 
+// GetRole returns the role the specs are installed with.
+func (p *PackageInstallOptions) GetRole() PackageRole {
+	return p.role
+}
+
+// SetRole sets the role the specs are installed with, i.e. whether they are added
+// to dependencies, devDependencies, optionalDependencies or peerDependencies.
+func (p *PackageInstallOptions) SetRole(role PackageRole) {
+	C.frida_package_install_options_set_role(p.p, (C.FridaPackageRole)(role))
+	p.role = role
+}
+
+// GetOmits returns the roles whose dependencies are not installed.
+func (p *PackageInstallOptions) GetOmits() []PackageRole {
+	omits := make([]PackageRole, len(p.omits))
+	copy(omits, p.omits)
+	return omits
+}
+
+// AddOmit skips installing the dependencies with the role.
+func (p *PackageInstallOptions) AddOmit(role PackageRole) {
+	C.frida_package_install_options_add_omit(p.p, (C.FridaPackageRole)(role))
+	p.omits = append(p.omits, role)
+}
+
+// ClearOmits removes the roles added with AddOmit.
+func (p *PackageInstallOptions) ClearOmits() {
+	C.frida_package_install_options_clear_omits(p.p)
+	p.omits = nil
+}
+
+// GetSpecs returns the specs added with AddSpec.
+func (p *PackageInstallOptions) GetSpecs() []string {
+	specs := make([]string, len(p.specs))
+	copy(specs, p.specs)
+	return specs
+}
+
 func (p *PackageInstallOptions) ClearSpecs() {
 	C.frida_package_install_options_clear_specs(p.p)
+	p.specs = nil
 }
 
 func (p *PackageInstallOptions) AddSpec(spec string) {
@@ -153,6 +394,39 @@ func (p *PackageInstallOptions) AddSpec(spec string) {
 	defer C.free(unsafe.Pointer(specC))
 
 	C.frida_package_install_options_add_spec(p.p, specC)
+	p.specs = append(p.specs, spec)
+}
+
+// AddPackageSpec validates and adds the spec.
+func (p *PackageInstallOptions) AddPackageSpec(spec string) error {
+	if _, err := ParsePackageSpec(spec); err != nil {
+		return err
+	}
+	p.AddSpec(spec)
+	return nil
+}
+
+// Clean will clean the resources held by the options.
+func (p *PackageInstallOptions) Clean() {
+	clean(unsafe.Pointer(p.p), unrefFrida)
+}
+
+// withSpecs returns new options for frida with the same project root, role and omits
+// but with specs instead of the ones added to p, p itself is left untouched.
+// The caller needs to Clean the returned options.
+func (p *PackageInstallOptions) withSpecs(specs []string) *PackageInstallOptions {
+	o := NewPackageInstallOptions()
+	if root := p.GetProjectRoot(); root != "" {
+		o.SetProjectRoot(root)
+	}
+	o.SetRole(p.role)
+	for _, role := range p.omits {
+		o.AddOmit(role)
+	}
+	for _, spec := range specs {
+		o.AddSpec(spec)
+	}
+	return o
 }
 
 type PackageInstallResult struct {
@@ -163,3 +437,13 @@ func (p *PackageInstallResult) GetPackages() *PackageList {
 	rt := C.frida_package_install_result_get_packages(p.p)
 	return &PackageList{rt}
 }
+
+// Packages returns the installed packages as Go slice.
+func (p *PackageInstallResult) Packages() []PackageInfo {
+	return p.GetPackages().Packages()
+}
+
+// Clean will clean the resources held by the install result.
+func (p *PackageInstallResult) Clean() {
+	clean(unsafe.Pointer(p.p), unrefFrida)
+}
@@ -0,0 +1,521 @@
+package frida
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// maxPackageDirDepth limits the nesting while copying the local package directory
+// so that the symlink cycles are not followed forever.
+const maxPackageDirDepth = 64
+
+// packageNameRe matches the package names accepted by npm, optionally scoped; upper case
+// letters are allowed for the legacy packages such as JSONStream.
+var packageNameRe = regexp.MustCompile(`^(?:@[a-zA-Z0-9-*~][a-zA-Z0-9-*._~]*/)?[a-zA-Z0-9-~][a-zA-Z0-9-._~]*$`)
+
+type localPackageSource struct {
+	name string
+	path string
+}
+
+type packageLock struct {
+	Packages map[string]struct {
+		Version   string `json:"version"`
+		Integrity string `json:"integrity"`
+	} `json:"packages"`
+}
+
+type packageManifest struct {
+	Name                 string            `json:"name"`
+	Version              string            `json:"version"`
+	Description          string            `json:"description"`
+	Homepage             string            `json:"homepage"`
+	Dependencies         map[string]string `json:"dependencies"`
+	DevDependencies      map[string]string `json:"devDependencies"`
+	OptionalDependencies map[string]string `json:"optionalDependencies"`
+}
+
+// AddLocalSource installs the package name from the directory or the .tgz tarball at path
+// instead of fetching it from the registry. An error is returned if name is not a valid npm package name.
+// Symlinks inside the directory are followed as long as they resolve inside of it.
+func (p *PackageInstallOptions) AddLocalSource(name, path string) error {
+	if err := validatePackageName(name); err != nil {
+		return err
+	}
+	p.localSources = append(p.localSources, localPackageSource{name: name, path: path})
+	return nil
+}
+
+// validatePackageName checks the name against the npm package name rules, which also
+// guarantees the name can be used as the path inside node_modules.
+func validatePackageName(name string) error {
+	if len(name) == 0 || len(name) > 214 || !packageNameRe.MatchString(name) {
+		return fmt.Errorf("invalid package name %q", name)
+	}
+	return nil
+}
+
+// isWithin returns whether pth is dir or is inside of it, both paths must be clean and absolute.
+func isWithin(dir, pth string) bool {
+	rel, err := filepath.Rel(dir, pth)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// ClearLocalSources removes the local sources added with AddLocalSource.
+func (p *PackageInstallOptions) ClearLocalSources() {
+	p.localSources = nil
+}
+
+// GetLocalSources returns the local sources mapping package names to paths.
+func (p *PackageInstallOptions) GetLocalSources() map[string]string {
+	sources := make(map[string]string, len(p.localSources))
+	for _, src := range p.localSources {
+		sources[src.name] = src.path
+	}
+	return sources
+}
+
+// SetLockfile sets the path of the package-lock.json to use; it is copied into the project root
+// before installing and updated from the project root after the install succeeds.
+// Integrity of the local tarballs is verified against it.
+func (p *PackageInstallOptions) SetLockfile(path string) {
+	p.lockfile = path
+}
+
+// GetLockfile returns the path of the lockfile.
+func (p *PackageInstallOptions) GetLockfile() string {
+	return p.lockfile
+}
+
+// SetOffline sets the offline mode in which only local sources and already installed
+// packages are used, ErrPackageOffline is returned if anything else is needed.
+// Specs already present in node_modules are satisfied by the installed package as long
+// as its version matches exact versions, version ranges accept any installed version.
+func (p *PackageInstallOptions) SetOffline(offline bool) {
+	p.offline = offline
+}
+
+// GetOffline returns whether the offline mode is set.
+func (p *PackageInstallOptions) GetOffline() bool {
+	return p.offline
+}
+
+func (p *PackageInstallOptions) hasLocalOptions() bool {
+	return p != nil && (len(p.localSources) > 0 || p.lockfile != "" || p.offline)
+}
+
+func (p *PackageManager) installLocal(ctx context.Context, installOpts *PackageInstallOptions) ([]PackageInfo, error) {
+	root := installOpts.GetProjectRoot()
+	if root == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return nil, err
+		}
+		root = wd
+	}
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+
+	lock, err := useLockfile(root, installOpts.lockfile)
+	if err != nil {
+		return nil, err
+	}
+
+	var pkgs []PackageInfo
+	local := make(map[string]bool)
+	for _, src := range installOpts.localSources {
+		info, err := installLocalSource(root, src, lock)
+		if err != nil {
+			return pkgs, fmt.Errorf("%s: %w", src.name, err)
+		}
+		pkgs = append(pkgs, info)
+		local[src.name] = true
+	}
+
+	var remaining []string
+	for _, spec := range installOpts.specs {
+		if ps, err := ParsePackageSpec(spec); err != nil || !local[ps.Name] {
+			remaining = append(remaining, spec)
+		}
+	}
+
+	if installOpts.offline {
+		var missing []string
+		for _, spec := range remaining {
+			if !isInstalled(root, spec) {
+				missing = append(missing, spec)
+			}
+		}
+		deps, err := missingDependencies(root, local)
+		if err != nil {
+			return pkgs, err
+		}
+		missing = append(missing, deps...)
+		if len(missing) > 0 {
+			return pkgs, fmt.Errorf("%w: %s", ErrPackageOffline, strings.Join(missing, ", "))
+		}
+		return pkgs, nil
+	}
+
+	fridaOpts := installOpts
+	if len(remaining) != len(installOpts.specs) {
+		fridaOpts = installOpts.withSpecs(remaining)
+		defer fridaOpts.Clean()
+	}
+
+	installed, err := p.installWithContext(ctx, fridaOpts)
+	pkgs = append(pkgs, installed...)
+	if err != nil {
+		return pkgs, err
+	}
+
+	if installOpts.lockfile != "" {
+		if err := copyLockfile(filepath.Join(root, "package-lock.json"), installOpts.lockfile); err != nil {
+			return pkgs, err
+		}
+	}
+	return pkgs, nil
+}
+
+// useLockfile copies the lockfile into the project root and parses it, nil is returned
+// if lockfile is empty or doesn't exist yet.
+func useLockfile(root, lockfile string) (*packageLock, error) {
+	if lockfile == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(lockfile)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var lock packageLock
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, fmt.Errorf("invalid lockfile %s: %w", lockfile, err)
+	}
+
+	dst := filepath.Join(root, "package-lock.json")
+	if abs, _ := filepath.Abs(lockfile); abs != dst {
+		if err := os.WriteFile(dst, data, 0o644); err != nil {
+			return nil, err
+		}
+	}
+	return &lock, nil
+}
+
+func copyLockfile(src, dst string) error {
+	if abs, _ := filepath.Abs(dst); abs == src {
+		return nil
+	}
+
+	data, err := os.ReadFile(src)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(dst, data, 0o644)
+}
+
+func installLocalSource(root string, src localPackageSource, lock *packageLock) (PackageInfo, error) {
+	if err := validatePackageName(src.name); err != nil {
+		return PackageInfo{}, err
+	}
+
+	nodeModules := filepath.Join(root, "node_modules")
+	dest := filepath.Join(nodeModules, filepath.FromSlash(src.name))
+	if dest == nodeModules || !isWithin(nodeModules, dest) {
+		return PackageInfo{}, fmt.Errorf("package %q resolves outside of node_modules", src.name)
+	}
+
+	fi, err := os.Stat(src.path)
+	if err != nil {
+		return PackageInfo{}, err
+	}
+
+	if err := os.RemoveAll(dest); err != nil {
+		return PackageInfo{}, err
+	}
+
+	if fi.IsDir() {
+		err = copyPackageDir(dest, src.path)
+	} else {
+		var data []byte
+		data, err = os.ReadFile(src.path)
+		if err != nil {
+			return PackageInfo{}, err
+		}
+		if lock != nil {
+			if entry, ok := lock.Packages["node_modules/"+src.name]; ok && entry.Integrity != "" {
+				if err := verifyIntegrity(data, entry.Integrity); err != nil {
+					return PackageInfo{}, err
+				}
+			}
+		}
+		err = extractTarball(dest, data)
+	}
+	if err != nil {
+		return PackageInfo{}, err
+	}
+
+	manifest, err := readPackageManifest(dest)
+	if err != nil {
+		return PackageInfo{}, err
+	}
+	name := manifest.Name
+	if name == "" {
+		name = src.name
+	}
+	return PackageInfo{
+		Name:        name,
+		Version:     manifest.Version,
+		Description: manifest.Description,
+		URL:         manifest.Homepage,
+	}, nil
+}
+
+// copyPackageDir copies the package directory into dst following the symlinks which resolve
+// inside of the directory, symlinks pointing outside of it are rejected.
+func copyPackageDir(dst, dir string) error {
+	root, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return err
+	}
+	root, err = filepath.Abs(root)
+	if err != nil {
+		return err
+	}
+	return copyResolvedDir(dst, root, root, 0)
+}
+
+func copyResolvedDir(dst, dir, root string, depth int) error {
+	if depth > maxPackageDirDepth {
+		return fmt.Errorf("%s: too deeply nested, symlink cycle?", dir)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dst, 0o755); err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		src := filepath.Join(dir, entry.Name())
+		target := filepath.Join(dst, entry.Name())
+
+		mode := entry.Type()
+		if mode&fs.ModeSymlink != 0 {
+			resolved, err := filepath.EvalSymlinks(src)
+			if err != nil {
+				return err
+			}
+			if !isWithin(root, resolved) {
+				return fmt.Errorf("%s: symlink points outside of the package", src)
+			}
+			fi, err := os.Stat(resolved)
+			if err != nil {
+				return err
+			}
+			src, mode = resolved, fi.Mode().Type()
+		}
+
+		switch {
+		case mode.IsDir():
+			err = copyResolvedDir(target, src, root, depth+1)
+		case mode.IsRegular():
+			err = copyFSFile(target, os.DirFS(filepath.Dir(src)), filepath.Base(src))
+		default:
+			err = fmt.Errorf("%s: unsupported file type %s", src, mode)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// verifyIntegrity checks data against the subresource integrity string used by npm lockfiles.
+func verifyIntegrity(data []byte, integrity string) error {
+	hashes := map[string]func() hash.Hash{
+		"sha512": sha512.New,
+		"sha384": sha512.New384,
+		"sha256": sha256.New,
+		"sha1":   sha1.New,
+	}
+
+	checked := false
+	for _, entry := range strings.Fields(integrity) {
+		algo, digest, ok := strings.Cut(entry, "-")
+		newHash, known := hashes[algo]
+		if !ok || !known {
+			continue
+		}
+		checked = true
+
+		h := newHash()
+		h.Write(data)
+		if base64.StdEncoding.EncodeToString(h.Sum(nil)) == digest {
+			return nil
+		}
+	}
+
+	if checked {
+		return ErrPackageIntegrity
+	}
+	return nil
+}
+
+// extractTarball extracts npm package tarball into dest, the top level directory
+// of the tarball (usually "package") is stripped.
+func extractTarball(dest string, data []byte) error {
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		name := path.Clean(strings.TrimPrefix(hdr.Name, "/"))
+		_, name, _ = strings.Cut(name, "/")
+		if name == "" {
+			continue
+		}
+		if name == ".." || strings.HasPrefix(name, "../") {
+			return fmt.Errorf("invalid path %q in tarball", hdr.Name)
+		}
+		target := filepath.Join(dest, filepath.FromSlash(name))
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0o755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+				return err
+			}
+			if err := writeTarFile(target, tr, hdr.FileInfo().Mode()); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func writeTarFile(target string, r io.Reader, mode os.FileMode) error {
+	f, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode.Perm()|0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func readPackageManifest(dir string) (*packageManifest, error) {
+	data, err := os.ReadFile(filepath.Join(dir, "package.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest packageManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, err
+	}
+	return &manifest, nil
+}
+
+// isInstalled reports whether the spec is satisfied by the package installed in the
+// node_modules of the project. Version ranges can't be resolved offline so any installed
+// version satisfies them, exact versions have to match.
+func isInstalled(root, spec string) bool {
+	ps, err := ParsePackageSpec(spec)
+	if err != nil {
+		return false
+	}
+	manifest, err := readPackageManifest(filepath.Join(root, "node_modules", filepath.FromSlash(ps.Name)))
+	if err != nil {
+		return false
+	}
+	if isExactVersion(ps.Version) {
+		return strings.TrimPrefix(ps.Version, "=") == manifest.Version
+	}
+	return true
+}
+
+// isExactVersion reports whether version is a full major.minor.patch version rather than
+// a range, a partial version or a tag.
+func isExactVersion(version string) bool {
+	version = strings.TrimPrefix(version, "=")
+	if version == "" || version[0] < '0' || version[0] > '9' || strings.Count(version, ".") < 2 {
+		return false
+	}
+	return !strings.ContainsAny(version, "^~<>*xX| ")
+}
+
+// missingDependencies returns the dependencies from the package.json of the project
+// which are neither installed nor provided as local sources.
+func missingDependencies(root string, local map[string]bool) ([]string, error) {
+	manifest, err := readPackageManifest(root)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var missing []string
+	for _, deps := range []map[string]string{
+		manifest.Dependencies,
+		manifest.DevDependencies,
+		manifest.OptionalDependencies,
+	} {
+		for name := range deps {
+			if local[name] {
+				continue
+			}
+			pth := filepath.Join(root, "node_modules", filepath.FromSlash(name), "package.json")
+			if _, err := os.Stat(pth); err != nil {
+				missing = append(missing, name)
+			}
+		}
+	}
+	sort.Strings(missing)
+	return missing, nil
+}
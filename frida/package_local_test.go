@@ -0,0 +1,324 @@
+package frida
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type tarEntry struct {
+	name     string
+	data     string
+	typeflag byte
+}
+
+func makeTarball(t *testing.T, entries []tarEntry) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, e := range entries {
+		hdr := &tar.Header{
+			Name:     e.name,
+			Typeflag: e.typeflag,
+			Mode:     0o644,
+			Size:     int64(len(e.data)),
+		}
+		switch e.typeflag {
+		case tar.TypeDir:
+			hdr.Mode, hdr.Size = 0o755, 0
+		case tar.TypeSymlink:
+			hdr.Linkname, hdr.Size = e.data, 0
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if hdr.Size > 0 {
+			if _, err := tw.Write([]byte(e.data)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestExtractTarball(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []tarEntry
+		files   map[string]string
+		wantErr bool
+	}{
+		{
+			name: "package",
+			entries: []tarEntry{
+				{name: "package/", typeflag: tar.TypeDir},
+				{name: "package/package.json", data: `{"name":"a"}`, typeflag: tar.TypeReg},
+				{name: "package/lib/index.js", data: "module.exports = 1;", typeflag: tar.TypeReg},
+			},
+			files: map[string]string{
+				"package.json": `{"name":"a"}`,
+				"lib/index.js": "module.exports = 1;",
+			},
+		},
+		{
+			name: "absolute path",
+			entries: []tarEntry{
+				{name: "/package/index.js", data: "abs", typeflag: tar.TypeReg},
+			},
+			files: map[string]string{"index.js": "abs"},
+		},
+		{
+			name: "dot dot inside of the package",
+			entries: []tarEntry{
+				{name: "package/lib/../index.js", data: "inside", typeflag: tar.TypeReg},
+			},
+			files: map[string]string{"index.js": "inside"},
+		},
+		{
+			name: "dot dot as the top level directory",
+			entries: []tarEntry{
+				{name: "package/../../evil.js", data: "evil", typeflag: tar.TypeReg},
+			},
+			files: map[string]string{"evil.js": "evil"},
+		},
+		{
+			name: "traversal",
+			entries: []tarEntry{
+				{name: "package/../../../evil.js", data: "evil", typeflag: tar.TypeReg},
+			},
+			wantErr: true,
+		},
+		{
+			name: "symlink is skipped",
+			entries: []tarEntry{
+				{name: "package/index.js", data: "index", typeflag: tar.TypeReg},
+				{name: "package/passwd", data: "/etc/passwd", typeflag: tar.TypeSymlink},
+			},
+			files: map[string]string{"index.js": "index"},
+		},
+	}
+
+	for _, tt := range tests {
+		root := t.TempDir()
+		dest := filepath.Join(root, "node_modules", "a")
+
+		err := extractTarball(dest, makeTarball(t, tt.entries))
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: extractTarball() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+
+		for name, want := range tt.files {
+			got, err := os.ReadFile(filepath.Join(dest, filepath.FromSlash(name)))
+			if err != nil {
+				t.Errorf("%s: %v", tt.name, err)
+				continue
+			}
+			if string(got) != want {
+				t.Errorf("%s: %s = %q, want %q", tt.name, name, got, want)
+			}
+		}
+
+		// nothing may be written next to the package
+		entries, err := os.ReadDir(filepath.Join(root, "node_modules"))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			t.Fatal(err)
+		}
+		for _, entry := range entries {
+			if entry.Name() != "a" {
+				t.Errorf("%s: %s written outside of the package", tt.name, entry.Name())
+			}
+		}
+		if _, err := os.Lstat(filepath.Join(dest, "passwd")); err == nil {
+			t.Errorf("%s: symlink was extracted", tt.name)
+		}
+	}
+
+	if err := extractTarball(t.TempDir(), []byte("not gzip")); err == nil {
+		t.Error("extractTarball() of invalid data succeeded")
+	}
+}
+
+func TestVerifyIntegrity(t *testing.T) {
+	data := []byte("package data")
+	sri := func(algo string, sum []byte) string {
+		return algo + "-" + base64.StdEncoding.EncodeToString(sum)
+	}
+	sum512 := sha512.Sum512(data)
+	sum384 := sha512.Sum384(data)
+	sum256 := sha256.Sum256(data)
+	sum1 := sha1.Sum(data)
+	other := sha512.Sum512([]byte("other data"))
+
+	tests := []struct {
+		integrity string
+		want      error
+	}{
+		{integrity: sri("sha512", sum512[:])},
+		{integrity: sri("sha384", sum384[:])},
+		{integrity: sri("sha256", sum256[:])},
+		{integrity: sri("sha1", sum1[:])},
+		{integrity: sri("sha512", other[:]), want: ErrPackageIntegrity},
+		{integrity: sri("sha1", sum256[:]), want: ErrPackageIntegrity},
+		{integrity: sri("sha512", other[:]) + " " + sri("sha1", sum1[:])},
+		{integrity: sri("md5", other[:])},
+		{integrity: "sha512"},
+		{integrity: ""},
+	}
+
+	for _, tt := range tests {
+		if err := verifyIntegrity(data, tt.integrity); !errors.Is(err, tt.want) {
+			t.Errorf("verifyIntegrity(%q) error = %v, want %v", tt.integrity, err, tt.want)
+		}
+	}
+}
+
+func TestValidatePackageName(t *testing.T) {
+	tests := []struct {
+		name    string
+		wantErr bool
+	}{
+		{name: "frida-java-bridge"},
+		{name: "@types/frida-gum"},
+		{name: "JSONStream"},
+		{name: "lodash.merge"},
+		{name: "a_b~c"},
+		{name: "", wantErr: true},
+		{name: ".hidden", wantErr: true},
+		{name: "_private", wantErr: true},
+		{name: "..", wantErr: true},
+		{name: "../evil", wantErr: true},
+		{name: "a/b", wantErr: true},
+		{name: "@scope", wantErr: true},
+		{name: "@scope/", wantErr: true},
+		{name: "@scope/../evil", wantErr: true},
+		{name: "has space", wantErr: true},
+		{name: string(bytes.Repeat([]byte("a"), 215)), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		if err := validatePackageName(tt.name); (err != nil) != tt.wantErr {
+			t.Errorf("validatePackageName(%q) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestLockfileRoundTrip(t *testing.T) {
+	const lockData = `{"packages":{"node_modules/a":{"version":"1.0.0","integrity":"sha512-abc"}}}`
+
+	dir := t.TempDir()
+	root := filepath.Join(dir, "project")
+	if err := os.Mkdir(root, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	lockfile := filepath.Join(dir, "frida.lock.json")
+	projectLock := filepath.Join(root, "package-lock.json")
+
+	// a missing lockfile is created after the install
+	lock, err := useLockfile(root, lockfile)
+	if err != nil || lock != nil {
+		t.Fatalf("useLockfile() of missing lockfile = %v, %v, want nil, nil", lock, err)
+	}
+
+	if err := os.WriteFile(lockfile, []byte(lockData), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	lock, err = useLockfile(root, lockfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry := lock.Packages["node_modules/a"]
+	if entry.Version != "1.0.0" || entry.Integrity != "sha512-abc" {
+		t.Errorf("useLockfile() entry = %+v", entry)
+	}
+	if got, err := os.ReadFile(projectLock); err != nil || string(got) != lockData {
+		t.Errorf("project lockfile = %q, %v, want %q", got, err, lockData)
+	}
+
+	// the install updates the lockfile of the project which is copied back
+	const updated = `{"packages":{}}`
+	if err := os.WriteFile(projectLock, []byte(updated), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := copyLockfile(projectLock, lockfile); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := os.ReadFile(lockfile); err != nil || string(got) != updated {
+		t.Errorf("lockfile = %q, %v, want %q", got, err, updated)
+	}
+
+	// the lockfile of the project itself is used in place
+	lock, err = useLockfile(root, projectLock)
+	if err != nil || lock == nil {
+		t.Fatalf("useLockfile() of project lockfile = %v, %v", lock, err)
+	}
+	if err := copyLockfile(projectLock, projectLock); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(lockfile, []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := useLockfile(root, lockfile); err == nil {
+		t.Error("useLockfile() of invalid lockfile succeeded")
+	}
+
+	if lock, err := useLockfile(root, ""); err != nil || lock != nil {
+		t.Errorf("useLockfile() without lockfile = %v, %v, want nil, nil", lock, err)
+	}
+}
+
+func TestIsInstalled(t *testing.T) {
+	root := t.TempDir()
+	for name, version := range map[string]string{
+		"frida-java-bridge": "6.2.3",
+		"@types/frida-gum":  "18.4.0",
+	} {
+		dir := filepath.Join(root, "node_modules", filepath.FromSlash(name))
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		manifest := `{"name":"` + name + `","version":"` + version + `"}`
+		if err := os.WriteFile(filepath.Join(dir, "package.json"), []byte(manifest), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		spec string
+		want bool
+	}{
+		{spec: "frida-java-bridge", want: true},
+		{spec: "frida-java-bridge@^6.0.0", want: true},
+		{spec: "frida-java-bridge@latest", want: true},
+		{spec: "frida-java-bridge@6.2.3", want: true},
+		{spec: "frida-java-bridge@=6.2.3", want: true},
+		{spec: "frida-java-bridge@6.2.4", want: false},
+		{spec: "@types/frida-gum@18.4.0", want: true},
+		{spec: "@types/frida-gum@18", want: true},
+		{spec: "frida-objc-bridge", want: false},
+		{spec: "@types", want: false},
+	}
+
+	for _, tt := range tests {
+		if got := isInstalled(root, tt.spec); got != tt.want {
+			t.Errorf("isInstalled(%q) = %v, want %v", tt.spec, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package frida
+
+import "testing"
+
+func TestParsePackageSpec(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    PackageSpec
+		wantErr bool
+	}{
+		{spec: "frida-java-bridge", want: PackageSpec{Name: "frida-java-bridge"}},
+		{spec: "frida-java-bridge@^6.0.0", want: PackageSpec{Name: "frida-java-bridge", Version: "^6.0.0"}},
+		{spec: "frida-java-bridge@latest", want: PackageSpec{Name: "frida-java-bridge", Version: "latest"}},
+		{spec: "@types/frida-gum", want: PackageSpec{Name: "@types/frida-gum"}},
+		{spec: "@types/frida-gum@18", want: PackageSpec{Name: "@types/frida-gum", Version: "18"}},
+		{spec: "", wantErr: true},
+		{spec: "@1.0.0", wantErr: true},
+		{spec: "frida-java-bridge@", wantErr: true},
+		{spec: "@types", wantErr: true},
+		{spec: "@types/", wantErr: true},
+		{spec: "@/frida-gum", wantErr: true},
+		{spec: "@types/frida-gum@", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParsePackageSpec(tt.spec)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParsePackageSpec(%q) error = %v, wantErr %v", tt.spec, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParsePackageSpec(%q) = %+v, want %+v", tt.spec, got, tt.want)
+		}
+		if !tt.wantErr && got.String() != tt.spec {
+			t.Errorf("ParsePackageSpec(%q).String() = %q", tt.spec, got.String())
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package frida
+
+//#include <frida-core.h>
+import "C"
+import (
+	"context"
+	"time"
+)
+
+// PeerConnectionState represents the state of the peer connection setup.
+// frida does not report any progress while the connection is set up, so PeerConnectionConnecting
+// only marks the start of the frida call and the other states mark its outcome.
+type PeerConnectionState int
+
+const (
+	PeerConnectionConnecting PeerConnectionState = iota
+	PeerConnectionEstablished
+	PeerConnectionFailed
+	PeerConnectionCancelled
+)
+
+func (p PeerConnectionState) String() string {
+	return [...]string{"connecting",
+		"established",
+		"failed",
+		"cancelled"}[p]
+}
+
+// PeerConnectionEvent is passed to the function set with PeerOptions.OnProgress.
+type PeerConnectionEvent struct {
+	State   PeerConnectionState
+	Elapsed time.Duration // time since the setup started
+	Err     error         // populated for PeerConnectionFailed and PeerConnectionCancelled
+}
+
+// PeerConnectionReport is the outcome of Session.SetupPeerConnectionWithContext.
+// frida does not expose the selected ICE candidate pair, so the report holds the STUN server and
+// the relays the connection could fall back to; with no relays configured only the direct and
+// STUN derived candidates were tried. Relays are borrowed from the PeerOptions, see PeerOptions.Relays.
+type PeerConnectionReport struct {
+	State      PeerConnectionState
+	StunServer string
+	Relays     []*Relay
+	Duration   time.Duration
+	Err        error
+}
+
+// SetupPeerConnectionWithContext runs SetupPeerConnection but with context, validating the peer options
+// first, see PeerOptions.Validate. opts can be nil to use the defaults.
+// The report is returned even if the setup fails, holding the reason in Err.
+// The function set with PeerOptions.OnProgress is called with PeerConnectionConnecting right before
+// the setup starts and with the outcome once it is done; validation failures are only reported as failed.
+//
+// Example:
+//
+//	opts := frida.NewPeerOptions()
+//	opts.SetStunServer("stun.example.com:3478")
+//	relay, _ := frida.ParseRelayURL("turns:turn.example.com", "user", "secret")
+//	opts.AddRelay(relay)
+//	opts.OnProgress(func(ev frida.PeerConnectionEvent) {
+//		fmt.Println(ev.State, ev.Elapsed)
+//	})
+//	report, err := session.SetupPeerConnectionWithContext(ctx, opts)
+func (s *Session) SetupPeerConnectionWithContext(ctx context.Context, opts *PeerOptions) (*PeerConnectionReport, error) {
+	start := time.Now()
+	report := &PeerConnectionReport{}
+
+	var progress func(ev PeerConnectionEvent)
+	if opts != nil {
+		progress = opts.onProgress
+		report.StunServer = opts.StunServer()
+		report.Relays = opts.Relays()
+	}
+
+	set := func(state PeerConnectionState, err error) {
+		report.State = state
+		report.Err = err
+		report.Duration = time.Since(start)
+		if progress != nil {
+			progress(PeerConnectionEvent{State: state, Elapsed: report.Duration, Err: err})
+		}
+	}
+
+	if opts != nil {
+		if err := opts.Validate(); err != nil {
+			set(PeerConnectionFailed, err)
+			return report, err
+		}
+	}
+
+	set(PeerConnectionConnecting, nil)
+	_, err := handleWithContext(ctx, func(c *Cancellable, doneC chan any, errC chan error) {
+		if err := s.SetupPeerConnection(opts, WithCancel(c)); err != nil {
+			errC <- err
+			return
+		}
+		doneC <- nil
+	})
+
+	switch {
+	case err == ErrContextCancelled:
+		set(PeerConnectionCancelled, err)
+	case err != nil:
+		set(PeerConnectionFailed, err)
+	default:
+		set(PeerConnectionEstablished, nil)
+	}
+	return report, err
+}
+
+// SetupPeerConnection sets up peer (p2p) connection with peer options provided, peerOpts can be nil
+// to use the defaults.
+func (s *Session) SetupPeerConnection(peerOpts *PeerOptions, opts ...OptFunc) error {
+	if err := s.acquire(); err != nil {
+		return err
+	}
+	defer s.release()
+
+	var po *C.FridaPeerOptions = nil
+	if peerOpts != nil {
+		po = peerOpts.opts
+	}
+
+	o := setupOptions(opts)
+
+	var err *C.GError
+	C.frida_session_setup_peer_connection_sync(s.s, po, o.cancellable, &err)
+	return handleGError(err)
+}
@@ -3,18 +3,24 @@ package frida
 //#include <frida-core.h>
 import "C"
 import (
+	"fmt"
+	"net"
 	"unsafe"
 )
 
 // PeerOptions type represents struct used to setup p2p connection.
 type PeerOptions struct {
-	opts *C.FridaPeerOptions
+	opts       *C.FridaPeerOptions
+	relays     []*Relay
+	onProgress func(ev PeerConnectionEvent)
 }
 
 // NewPeerOptions creates new empty peer options.
 func NewPeerOptions() *PeerOptions {
 	opts := C.frida_peer_options_new()
-	return &PeerOptions{opts}
+	return &PeerOptions{
+		opts: opts,
+	}
 }
 
 // StunServer returns the stun server for peer options.
@@ -22,14 +28,46 @@ func (p *PeerOptions) StunServer() string {
 	return C.GoString(C.frida_peer_options_get_stun_server(p.opts))
 }
 
+// Relays returns the relays added to the peer options. The relays are borrowed, they are
+// the ones passed to AddRelay and stay owned by the caller, so they must not be cleaned through the returned slice.
+func (p *PeerOptions) Relays() []*Relay {
+	relays := make([]*Relay, len(p.relays))
+	copy(relays, p.relays)
+	return relays
+}
+
 // ClearRelays removes previously added relays.
 func (p *PeerOptions) ClearRelays() {
 	C.frida_peer_options_clear_relays(p.opts)
+	p.relays = nil
 }
 
 // AddRelay adds new relay to use for peer options.
 func (p *PeerOptions) AddRelay(relay *Relay) {
 	C.frida_peer_options_add_relay(p.opts, relay.r)
+	p.relays = append(p.relays, relay)
+}
+
+// OnProgress sets the function called by Session.SetupPeerConnectionWithContext before the setup starts and once it is done.
+func (p *PeerOptions) OnProgress(fn func(ev PeerConnectionEvent)) {
+	p.onProgress = fn
+}
+
+// Validate checks the stun server and the relays of the peer options.
+func (p *PeerOptions) Validate() error {
+	if stun := p.StunServer(); stun != "" {
+		host, _, err := net.SplitHostPort(stun)
+		if err != nil || host == "" {
+			return fmt.Errorf("%w: %s is not in the host:port form", ErrInvalidStunServer, stun)
+		}
+	}
+
+	for i, relay := range p.relays {
+		if err := relay.Validate(); err != nil {
+			return fmt.Errorf("relay %d: %w", i, err)
+		}
+	}
+	return nil
 }
 
 // SetStunServer sets the stun server for peer options.
@@ -0,0 +1,283 @@
+package frida
+
+//#include <frida-core.h>
+import "C"
+import (
+	"context"
+	"runtime"
+	"sort"
+	"unsafe"
+)
+
+// PortalEvent is implemented by all the events sent by Portal.Events.
+type PortalEvent interface {
+	isPortalEvent()
+}
+
+// NodeConnected is sent when the node connects to the cluster endpoint.
+type NodeConnected struct {
+	ConnectionID  uint
+	RemoteAddress *Address
+}
+
+// NodeJoined is sent when the application on the node joins the portal.
+type NodeJoined struct {
+	ConnectionID  uint
+	RemoteAddress *Address
+	Application   *Application
+}
+
+// NodeLeft is sent when the application on the node leaves the portal.
+type NodeLeft struct {
+	ConnectionID  uint
+	RemoteAddress *Address
+	Application   *Application
+}
+
+// NodeDisconnected is sent when the node disconnects from the cluster endpoint.
+type NodeDisconnected struct {
+	ConnectionID  uint
+	RemoteAddress *Address
+}
+
+// ControllerConnected is sent when the controller connects to the control endpoint.
+type ControllerConnected struct {
+	ConnectionID  uint
+	RemoteAddress *Address
+}
+
+// ControllerDisconnected is sent when the controller disconnects from the control endpoint.
+type ControllerDisconnected struct {
+	ConnectionID  uint
+	RemoteAddress *Address
+}
+
+// Authenticated is sent when the controller authenticates, SessionInfo is the JSON
+// returned by the authentication service.
+type Authenticated struct {
+	ConnectionID uint
+	SessionInfo  string
+}
+
+// Subscribed is sent when the controller subscribes to the messages.
+type Subscribed struct {
+	ConnectionID uint
+}
+
+// PortalMessage is the message posted by the controller.
+type PortalMessage struct {
+	ConnectionID uint
+	Message      string
+	Data         []byte
+}
+
+func (NodeConnected) isPortalEvent()          {}
+func (NodeJoined) isPortalEvent()             {}
+func (NodeLeft) isPortalEvent()               {}
+func (NodeDisconnected) isPortalEvent()       {}
+func (ControllerConnected) isPortalEvent()    {}
+func (ControllerDisconnected) isPortalEvent() {}
+func (Authenticated) isPortalEvent()          {}
+func (Subscribed) isPortalEvent()             {}
+func (PortalMessage) isPortalEvent()          {}
+
+// PortalNode is the node connected to the portal, Application is nil
+// until the node joins.
+type PortalNode struct {
+	ConnectionID  uint
+	RemoteAddress *Address
+	Application   *Application
+}
+
+// Joined returns whether the application on the node has joined the portal.
+func (n PortalNode) Joined() bool {
+	return n.Application != nil
+}
+
+// PortalController is the controller connected to the portal.
+type PortalController struct {
+	ConnectionID  uint
+	RemoteAddress *Address
+	Authenticated bool
+	SessionInfo   string
+	Subscribed    bool
+}
+
+// Events returns the channel receiving the portal events until ctx is done.
+// Events are buffered so the slow reader doesn't block the portal.
+//
+// Example:
+//
+//	for ev := range portal.Events(ctx) {
+//		switch e := ev.(type) {
+//		case frida.NodeJoined:
+//			fmt.Println("joined:", e.ConnectionID, e.Application.Identifier())
+//		case frida.NodeLeft:
+//			fmt.Println("left:", e.ConnectionID)
+//		}
+//	}
+func (p *Portal) Events(ctx context.Context) <-chan PortalEvent {
+	return p.events.subscribe(ctx)
+}
+
+// Nodes returns the nodes whose applications are currently joined, sorted by connection ID.
+func (p *Portal) Nodes() []PortalNode {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	nodes := make([]PortalNode, 0, len(p.nodes))
+	for _, node := range p.nodes {
+		if node.Joined() {
+			nodes = append(nodes, *node)
+		}
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].ConnectionID < nodes[j].ConnectionID
+	})
+	return nodes
+}
+
+// Node returns the connected node with the connectionID, whether joined or not.
+func (p *Portal) Node(connectionID uint) (PortalNode, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	node, ok := p.nodes[connectionID]
+	if !ok {
+		return PortalNode{}, false
+	}
+	return *node, true
+}
+
+// Controllers returns the currently connected controllers, sorted by connection ID.
+func (p *Portal) Controllers() []PortalController {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	controllers := make([]PortalController, 0, len(p.controllers))
+	for _, ctrl := range p.controllers {
+		controllers = append(controllers, *ctrl)
+	}
+	sort.Slice(controllers, func(i, j int) bool {
+		return controllers[i].ConnectionID < controllers[j].ConnectionID
+	})
+	return controllers
+}
+
+// Controller returns the connected controller with the connectionID.
+func (p *Portal) Controller(connectionID uint) (PortalController, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	ctrl, ok := p.controllers[connectionID]
+	if !ok {
+		return PortalController{}, false
+	}
+	return *ctrl, true
+}
+
+// track connects to the portal signals to maintain the registry and send the events.
+func (p *Portal) track() {
+	p.nodes = make(map[uint]*PortalNode)
+	p.controllers = make(map[uint]*PortalController)
+
+	obj := unsafe.Pointer(p.portal)
+	p.handlers = []C.gulong{
+		connectClosure(obj, "node-connected", func(connID uint, addr *Address) {
+			p.mu.Lock()
+			p.nodes[connID] = &PortalNode{ConnectionID: connID, RemoteAddress: addr}
+			p.mu.Unlock()
+			p.events.emit(NodeConnected{ConnectionID: connID, RemoteAddress: addr})
+		}),
+		connectClosure(obj, "node-joined", func(connID uint, app *Application) {
+			app = retainApplication(app)
+
+			p.mu.Lock()
+			node, ok := p.nodes[connID]
+			if !ok {
+				node = &PortalNode{ConnectionID: connID}
+				p.nodes[connID] = node
+			}
+			node.Application = app
+			addr := node.RemoteAddress
+			p.mu.Unlock()
+
+			p.events.emit(NodeJoined{ConnectionID: connID, RemoteAddress: addr, Application: app})
+		}),
+		connectClosure(obj, "node-left", func(connID uint, app *Application) {
+			app = retainApplication(app)
+
+			var addr *Address
+			p.mu.Lock()
+			if node, ok := p.nodes[connID]; ok {
+				node.Application = nil
+				addr = node.RemoteAddress
+			}
+			p.mu.Unlock()
+
+			p.events.emit(NodeLeft{ConnectionID: connID, RemoteAddress: addr, Application: app})
+		}),
+		connectClosure(obj, "node-disconnected", func(connID uint, addr *Address) {
+			p.mu.Lock()
+			delete(p.nodes, connID)
+			p.mu.Unlock()
+			p.events.emit(NodeDisconnected{ConnectionID: connID, RemoteAddress: addr})
+		}),
+		connectClosure(obj, "controller-connected", func(connID uint, addr *Address) {
+			p.mu.Lock()
+			p.controllers[connID] = &PortalController{ConnectionID: connID, RemoteAddress: addr}
+			p.mu.Unlock()
+			p.events.emit(ControllerConnected{ConnectionID: connID, RemoteAddress: addr})
+		}),
+		connectClosure(obj, "controller-disconnected", func(connID uint, addr *Address) {
+			p.mu.Lock()
+			delete(p.controllers, connID)
+			p.mu.Unlock()
+			p.events.emit(ControllerDisconnected{ConnectionID: connID, RemoteAddress: addr})
+		}),
+		connectClosure(obj, "authenticated", func(connID uint, sessionInfo string) {
+			p.mu.Lock()
+			if ctrl, ok := p.controllers[connID]; ok {
+				ctrl.Authenticated = true
+				ctrl.SessionInfo = sessionInfo
+			}
+			p.mu.Unlock()
+			p.events.emit(Authenticated{ConnectionID: connID, SessionInfo: sessionInfo})
+		}),
+		connectClosure(obj, "subscribe", func(connID uint) {
+			p.mu.Lock()
+			if ctrl, ok := p.controllers[connID]; ok {
+				ctrl.Subscribed = true
+			}
+			p.mu.Unlock()
+			p.events.emit(Subscribed{ConnectionID: connID})
+		}),
+		connectClosure(obj, "message", func(connID uint, message string, data []byte) {
+			p.events.emit(PortalMessage{ConnectionID: connID, Message: message, Data: data})
+		}),
+	}
+}
+
+// untrack disconnects the handlers and closes the event channels.
+func (p *Portal) untrack() {
+	for _, id := range p.handlers {
+		disconnectClosure(unsafe.Pointer(p.portal), id)
+	}
+	p.handlers = nil
+	p.events.close()
+}
+
+// retainApplication takes the reference on the application received in the signal
+// so that it can be used after the handler returns.
+func retainApplication(app *Application) *Application {
+	if app == nil || app.application == nil {
+		return app
+	}
+
+	C.g_object_ref(C.gpointer(app.application))
+	retained := &Application{app.application}
+	runtime.SetFinalizer(retained, func(a *Application) {
+		a.Clean()
+	})
+	return retained
+}
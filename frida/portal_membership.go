@@ -2,21 +2,310 @@ package frida
 
 //#include <frida-core.h>
 import "C"
-import "unsafe"
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+	"unsafe"
+)
+
+// MembershipState represents the state of the PortalMembership.
+type MembershipState int
+
+const (
+	MembershipJoined MembershipState = iota
+	MembershipDisconnected
+	MembershipRejoining
+	MembershipTerminated
+)
+
+func (m MembershipState) String() string {
+	return [...]string{"joined",
+		"disconnected",
+		"rejoining",
+		"terminated"}[m]
+}
+
+// MembershipEvent is passed to the RejoinOptions.OnStateChange callback.
+type MembershipEvent struct {
+	State   MembershipState
+	Attempt int   // number of join attempts made, populated when rejoining
+	Err     error // the error causing the state change, if any
+
+	stop func()
+}
+
+// StopAutoRejoin stops the auto re-join from the OnStateChange callback without waiting for
+// the callback to return; no further callbacks are made.
+func (ev MembershipEvent) StopAutoRejoin() {
+	if ev.stop != nil {
+		ev.stop()
+	}
+}
+
+// RejoinOptions configures the automatic re-join of the membership.
+// Zero values are replaced with the defaults.
+type RejoinOptions struct {
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	OnStateChange  func(ev MembershipEvent)
+}
 
 // PortalMembership type is used to join portal with session.
 type PortalMembership struct {
-	mem *C.FridaPortalMembership
+	mem     *C.FridaPortalMembership
+	session *Session
+	address string
+	opts    *PortalOptions
+
+	mu      sync.Mutex
+	state   MembershipState
+	bus     *Bus
+	handler C.gulong
+	cancel  context.CancelFunc
+	done    chan struct{}
+}
+
+// ID returns the ID of the membership.
+func (p *PortalMembership) ID() uint {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return uint(C.frida_portal_membership_get_id(p.mem))
 }
 
-// Terminate terminates the session membership
+// Address returns the address of the portal joined.
+func (p *PortalMembership) Address() string {
+	return p.address
+}
+
+// Options returns the options the membership was created with, nil if none were provided.
+func (p *PortalMembership) Options() *PortalOptions {
+	return p.opts
+}
+
+// State returns the current state of the membership.
+func (p *PortalMembership) State() MembershipState {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.state
+}
+
+// Terminate terminates the session membership, stopping the auto re-join first.
+// From the OnStateChange callback, call MembershipEvent.StopAutoRejoin before Terminate.
 func (p *PortalMembership) Terminate() error {
+	p.StopAutoRejoin()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
 	var err *C.GError
 	C.frida_portal_membership_terminate_sync(p.mem, nil, &err)
-	return handleGError(err)
+	if gErr := handleGError(err); gErr != nil {
+		return gErr
+	}
+	p.state = MembershipTerminated
+	return nil
+}
+
+// EnableAutoRejoin joins the portal again with exponential backoff whenever bus emits
+// the "detached" signal, e.g. when the portal gets restarted. bus is usually the bus of the
+// device added for the control endpoint of the same portal; it is attached again after every
+// re-join so that the next detach is noticed as well.
+// The options passed to JoinPortal are reused so they must not be cleaned while auto re-join is enabled.
+//
+// Example:
+//
+//	mem, err := session.JoinPortal("portal.example.com:27052", opts)
+//	// ...
+//	portalDevice, err := mgr.AddRemoteDevice("portal.example.com:27042", nil)
+//	// ...
+//	bus := portalDevice.Bus()
+//	if err := bus.Attach(); err != nil {
+//		panic(err)
+//	}
+//	err = mem.EnableAutoRejoin(bus, &frida.RejoinOptions{
+//		OnStateChange: func(ev frida.MembershipEvent) {
+//			fmt.Println("membership", ev.State)
+//		},
+//	})
+func (p *PortalMembership) EnableAutoRejoin(bus *Bus, opts *RejoinOptions) error {
+	if bus == nil {
+		return errors.New("you need to provide the bus to watch")
+	}
+
+	var o RejoinOptions
+	if opts != nil {
+		o = *opts
+	}
+
+	if o.InitialBackoff <= 0 {
+		o.InitialBackoff = defaultReconnectInitialBackoff
+	}
+	if o.MaxBackoff <= 0 {
+		o.MaxBackoff = defaultReconnectMaxBackoff
+	}
+	if o.MaxBackoff < o.InitialBackoff {
+		o.MaxBackoff = o.InitialBackoff
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.state == MembershipTerminated {
+		return errors.New("membership is terminated")
+	}
+	if p.cancel != nil {
+		return errors.New("auto rejoin is already enabled")
+	}
+
+	// signal is emitted on the frida thread so it only wakes up the supervisor
+	detached := make(chan struct{}, 1)
+	p.bus = bus
+	p.handler = connectClosure(unsafe.Pointer(bus.bus), "detached", func() {
+		select {
+		case detached <- struct{}{}:
+		default:
+		}
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+	p.done = make(chan struct{})
+	go p.supervise(ctx, o, detached, p.done)
+
+	return nil
+}
+
+// StopAutoRejoin stops the auto re-join and disconnects the "detached" handler, any join in
+// progress and the OnStateChange callback running are finished first. It must not be called
+// from the OnStateChange callback, which uses MembershipEvent.StopAutoRejoin instead.
+func (p *PortalMembership) StopAutoRejoin() {
+	if done := p.stopAutoRejoin(nil); done != nil {
+		<-done
+	}
+}
+
+// stopAutoRejoin stops the supervisor finishing with done, or the current one if done is nil,
+// and returns the channel closed once it exits; nil is returned if it was already stopped.
+func (p *PortalMembership) stopAutoRejoin(done chan struct{}) chan struct{} {
+	p.mu.Lock()
+	if p.cancel == nil || (done != nil && p.done != done) {
+		p.mu.Unlock()
+		return nil
+	}
+	cancel, current := p.cancel, p.done
+	bus, handler := p.bus, p.handler
+	p.cancel, p.done = nil, nil
+	p.bus, p.handler = nil, 0
+	p.mu.Unlock()
+
+	cancel()
+	disconnectClosure(unsafe.Pointer(bus.bus), handler)
+	return current
+}
+
+func (p *PortalMembership) supervise(ctx context.Context, o RejoinOptions, detached chan struct{}, done chan struct{}) {
+	defer close(done)
+
+	// the callback stops only this supervisor and must not wait for it to exit
+	stop := func() {
+		p.stopAutoRejoin(done)
+	}
+
+	// emit reports whether the supervisor should go on, the callback may stop it
+	emit := func(ev MembershipEvent) bool {
+		p.mu.Lock()
+		if ctx.Err() != nil {
+			p.mu.Unlock()
+			return false
+		}
+		p.state = ev.State
+		p.mu.Unlock()
+
+		if o.OnStateChange != nil {
+			ev.stop = stop
+			o.OnStateChange(ev)
+		}
+		return ctx.Err() == nil
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-detached:
+		}
+
+		if !emit(MembershipEvent{State: MembershipDisconnected}) {
+			return
+		}
+
+		backoff := o.InitialBackoff
+		for attempt := 1; ; attempt++ {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+
+			if !emit(MembershipEvent{State: MembershipRejoining, Attempt: attempt}) {
+				return
+			}
+			err := p.rejoin()
+			if err == nil {
+				if !emit(MembershipEvent{State: MembershipJoined, Attempt: attempt}) {
+					return
+				}
+				break
+			}
+			if errors.Is(err, ErrSessionClosed) {
+				emit(MembershipEvent{State: MembershipTerminated, Attempt: attempt, Err: err})
+				return
+			}
+			if !emit(MembershipEvent{State: MembershipDisconnected, Attempt: attempt, Err: err}) {
+				return
+			}
+
+			backoff *= 2
+			if backoff > o.MaxBackoff {
+				backoff = o.MaxBackoff
+			}
+		}
+	}
+}
+
+// rejoin terminates the stale membership, joins the portal again and attaches the bus
+// so that the next detach is noticed.
+func (p *PortalMembership) rejoin() error {
+	p.mu.Lock()
+	old, bus := p.mem, p.bus
+	p.mu.Unlock()
+
+	// the old membership is most likely gone with the portal, so the error is ignored
+	var gErr *C.GError
+	C.frida_portal_membership_terminate_sync(old, nil, &gErr)
+	handleGError(gErr)
+
+	mem, err := p.session.joinPortal(p.address, p.opts)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	p.mem = mem
+	p.mu.Unlock()
+
+	clean(unsafe.Pointer(old), unrefFrida)
+
+	if bus != nil && bus.IsDetached() {
+		return bus.Attach()
+	}
+	return nil
 }
 
 // Clean will clean the resources held by the portal membership.
 func (p *PortalMembership) Clean() {
+	p.StopAutoRejoin()
 	clean(unsafe.Pointer(p.mem), unrefFrida)
 }
@@ -17,9 +17,14 @@ func NewPortalOptions() *PortalOptions {
 	}
 }
 
-// Certificate returns the tls certificate for portal options.
+// Certificate returns the tls certificate for portal options, nil if none is set.
+// The certificate holds its own reference, so it should be cleaned with Clean.
 func (p *PortalOptions) Certificate() *Certificate {
 	cert := C.frida_portal_options_get_certificate(p.opts)
+	if cert == nil {
+		return nil
+	}
+	C.g_object_ref(C.gpointer(cert))
 	return &Certificate{cert}
 }
 
@@ -42,10 +47,18 @@ func (p *PortalOptions) SetCertificate(certPath string) error {
 		return err
 	}
 
+	defer cert.Clean()
+
 	C.frida_portal_options_set_certificate(p.opts, cert.cert)
 	return nil
 }
 
+// UseCertificate sets the certificate for the portal, such as the one
+// created with CertificateFromPEM or GenerateSelfSigned.
+func (p *PortalOptions) UseCertificate(cert *Certificate) {
+	C.frida_portal_options_set_certificate(p.opts, cert.cert)
+}
+
 // SetToken sets the token for the authentication.
 func (p *PortalOptions) SetToken(token string) {
 	tokenC := C.CString(token)
@@ -0,0 +1,365 @@
+package frida
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"sort"
+	"sync"
+)
+
+// RouteTarget is the controller connection the TagRule is evaluated for; SessionInfo is set
+// once the controller is authenticated.
+type RouteTarget struct {
+	ConnectionID  uint
+	RemoteAddress *Address
+	SessionInfo   string
+}
+
+// TagRule returns the tags the controller connection should be tagged with.
+type TagRule func(target RouteTarget) []string
+
+// TagBySessionInfo tags the authenticated controllers with the tags returned by fn for the
+// session info returned by the authentication service.
+func TagBySessionInfo(fn func(sessionInfo string) []string) TagRule {
+	return func(target RouteTarget) []string {
+		if target.SessionInfo == "" {
+			return nil
+		}
+		return fn(target.SessionInfo)
+	}
+}
+
+// TagByRemoteAddress tags the controllers connecting from the network with the tag.
+func TagByRemoteAddress(network *net.IPNet, tag string) TagRule {
+	return func(target RouteTarget) []string {
+		if target.RemoteAddress == nil {
+			return nil
+		}
+		if ip := net.ParseIP(target.RemoteAddress.Addr); ip != nil && network.Contains(ip) {
+			return []string{tag}
+		}
+		return nil
+	}
+}
+
+// PortalReply is the reply to the PortalRouter.Request.
+type PortalReply struct {
+	ConnectionID uint
+	Payload      json.RawMessage
+	Data         []byte
+}
+
+type routerRequest struct {
+	waiting map[uint]struct{}
+	replies []PortalReply
+	err     error
+	done    chan struct{}
+}
+
+// settle removes the connection from the waiting ones and marks the request as done
+// once no connections are left.
+func (q *routerRequest) settle(connectionID uint) {
+	if _, ok := q.waiting[connectionID]; !ok {
+		return
+	}
+	delete(q.waiting, connectionID)
+	if len(q.waiting) == 0 {
+		close(q.done)
+	}
+}
+
+// fail marks the request as done with err without waiting for the remaining connections.
+func (q *routerRequest) fail(err error) {
+	if len(q.waiting) == 0 {
+		return
+	}
+	q.waiting = nil
+	q.err = err
+	close(q.done)
+}
+
+type routerEnvelope struct {
+	Type    string          `json:"type"`
+	ID      uint64          `json:"id"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// PortalRouter tags the controller connections of the portal based on the rules and keeps the
+// index of the tags, so that the messages can be narrowcast to the controllers with the tag and
+// their replies collected. The tags also decide which nodes the controllers can see, as the
+// nodes joined with PortalOptions ACL are only visible to the controllers tagged with one of them.
+//
+// Only the controllers can be tagged and routed to. frida keeps no tags for the nodes, so the
+// nodes can't be grouped by their application. The ACL of a node is set by the node itself with
+// PortalOptions.SetACL when joining; the router only decides the controller tags it is matched against.
+//
+// Requests are sent as {"type": "request", "id": N, "payload": ...} and the controllers
+// reply by posting {"type": "reply", "id": N, "payload": ...} to the portal.
+type PortalRouter struct {
+	portal *Portal
+	rules  []TagRule
+	cancel context.CancelFunc
+
+	mu      sync.Mutex
+	index   map[string]map[uint]struct{}
+	tags    map[uint]map[string]struct{}
+	nextID  uint64
+	pending map[uint64]*routerRequest
+	closed  bool
+}
+
+// NewPortalRouter creates the router for the portal applying the rules to the controllers
+// connecting and authenticating. Controllers connected before the router was created are tagged immediately.
+func NewPortalRouter(portal *Portal, rules ...TagRule) *PortalRouter {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	r := &PortalRouter{
+		portal:  portal,
+		rules:   rules,
+		cancel:  cancel,
+		index:   make(map[string]map[uint]struct{}),
+		tags:    make(map[uint]map[string]struct{}),
+		pending: make(map[uint64]*routerRequest),
+	}
+
+	events := portal.Events(ctx)
+
+	for _, ctrl := range portal.Controllers() {
+		r.apply(RouteTarget{
+			ConnectionID:  ctrl.ConnectionID,
+			RemoteAddress: ctrl.RemoteAddress,
+			SessionInfo:   ctrl.SessionInfo,
+		})
+	}
+
+	go r.run(events)
+	return r
+}
+
+func (r *PortalRouter) run(events <-chan PortalEvent) {
+	for ev := range events {
+		switch e := ev.(type) {
+		case ControllerConnected:
+			r.apply(RouteTarget{
+				ConnectionID:  e.ConnectionID,
+				RemoteAddress: e.RemoteAddress,
+			})
+		case Authenticated:
+			ctrl, _ := r.portal.Controller(e.ConnectionID)
+			r.apply(RouteTarget{
+				ConnectionID:  e.ConnectionID,
+				RemoteAddress: ctrl.RemoteAddress,
+				SessionInfo:   e.SessionInfo,
+			})
+		case ControllerDisconnected:
+			r.forget(e.ConnectionID)
+		case PortalMessage:
+			r.handleMessage(e)
+		}
+	}
+}
+
+func (r *PortalRouter) apply(target RouteTarget) {
+	for _, rule := range r.rules {
+		for _, tag := range rule(target) {
+			r.Tag(target.ConnectionID, tag)
+		}
+	}
+}
+
+// forget removes the disconnected controller from the index, frida drops its tags on its own.
+func (r *PortalRouter) forget(connectionID uint) {
+	r.mu.Lock()
+	for _, req := range r.pending {
+		req.settle(connectionID)
+	}
+
+	tags := r.tags[connectionID]
+	for tag := range tags {
+		delete(r.index[tag], connectionID)
+		if len(r.index[tag]) == 0 {
+			delete(r.index, tag)
+		}
+	}
+	delete(r.tags, connectionID)
+	r.mu.Unlock()
+}
+
+// Tag tags the controller connection and adds it to the index.
+func (r *PortalRouter) Tag(connectionID uint, tag string) {
+	r.mu.Lock()
+	if _, ok := r.index[tag][connectionID]; ok {
+		r.mu.Unlock()
+		return
+	}
+	if r.index[tag] == nil {
+		r.index[tag] = make(map[uint]struct{})
+	}
+	r.index[tag][connectionID] = struct{}{}
+	if r.tags[connectionID] == nil {
+		r.tags[connectionID] = make(map[string]struct{})
+	}
+	r.tags[connectionID][tag] = struct{}{}
+	r.mu.Unlock()
+
+	r.portal.TagConnection(connectionID, tag)
+}
+
+// Untag untags the controller connection and removes it from the index.
+func (r *PortalRouter) Untag(connectionID uint, tag string) {
+	r.mu.Lock()
+	delete(r.index[tag], connectionID)
+	if len(r.index[tag]) == 0 {
+		delete(r.index, tag)
+	}
+	delete(r.tags[connectionID], tag)
+	r.mu.Unlock()
+
+	r.portal.UntagConnection(connectionID, tag)
+}
+
+// Tags returns all the tags in the index, sorted.
+func (r *PortalRouter) Tags() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tags := make([]string, 0, len(r.index))
+	for tag := range r.index {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// Connections returns the controller connections tagged with the tag, sorted.
+func (r *PortalRouter) Connections(tag string) []uint {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	conns := make([]uint, 0, len(r.index[tag]))
+	for conn := range r.index[tag] {
+		conns = append(conns, conn)
+	}
+	sort.Slice(conns, func(i, j int) bool {
+		return conns[i] < conns[j]
+	})
+	return conns
+}
+
+// Narrowcast sends v marshalled into JSON to all the controllers tagged with the tag.
+func (r *PortalRouter) Narrowcast(tag string, v any, data []byte) error {
+	msg, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	r.portal.Narrowcast(tag, string(msg), data)
+	return nil
+}
+
+// Request sends the payload to all the controllers tagged with the tag and waits for
+// their replies until all of them reply or ctx is done. On ctx done, the replies received so far are
+// returned with the error. Controllers disconnecting while the request is pending are not waited for.
+//
+// Only the controllers tagged through the router are waited for; the controllers tagged with
+// Portal.TagConnection directly receive the request but their replies are ignored. An error is
+// returned if the router knows no controllers tagged with the tag, and ErrRouterClosed once the
+// router is closed, pending requests included.
+func (r *PortalRouter) Request(ctx context.Context, tag string, payload any, data []byte) ([]PortalReply, error) {
+	rawPayload, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return nil, ErrRouterClosed
+	}
+	r.nextID++
+	id := r.nextID
+	req := &routerRequest{
+		waiting: make(map[uint]struct{}),
+		done:    make(chan struct{}),
+	}
+	for conn := range r.index[tag] {
+		req.waiting[conn] = struct{}{}
+	}
+	expected := len(req.waiting)
+	r.pending[id] = req
+	r.mu.Unlock()
+
+	defer func() {
+		r.mu.Lock()
+		delete(r.pending, id)
+		r.mu.Unlock()
+	}()
+
+	if expected == 0 {
+		return nil, fmt.Errorf("no connections tagged %q by the router", tag)
+	}
+
+	msg, err := json.Marshal(routerEnvelope{Type: "request", ID: id, Payload: rawPayload})
+	if err != nil {
+		return nil, err
+	}
+	r.portal.Narrowcast(tag, string(msg), data)
+
+	select {
+	case <-req.done:
+		err = nil
+	case <-ctx.Done():
+		err = ErrContextCancelled
+	}
+
+	r.mu.Lock()
+	replies := req.replies
+	if err == nil {
+		err = req.err
+	}
+	r.mu.Unlock()
+
+	if err != nil {
+		return replies, fmt.Errorf("%w: %d of %d replies received", err, len(replies), expected)
+	}
+	return replies, nil
+}
+
+func (r *PortalRouter) handleMessage(msg PortalMessage) {
+	var env routerEnvelope
+	if err := json.Unmarshal([]byte(msg.Message), &env); err != nil || env.Type != "reply" {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	req, ok := r.pending[env.ID]
+	if !ok {
+		return
+	}
+	if _, waiting := req.waiting[msg.ConnectionID]; !waiting {
+		return
+	}
+
+	req.replies = append(req.replies, PortalReply{
+		ConnectionID: msg.ConnectionID,
+		Payload:      env.Payload,
+		Data:         msg.Data,
+	})
+	req.settle(msg.ConnectionID)
+}
+
+// Close stops applying the rules, the tags already applied are kept.
+// Pending requests return ErrRouterClosed with the replies received so far.
+func (r *PortalRouter) Close() {
+	r.cancel()
+
+	r.mu.Lock()
+	r.closed = true
+	for _, req := range r.pending {
+		req.fail(ErrRouterClosed)
+	}
+	r.mu.Unlock()
+}
@@ -4,21 +4,31 @@ package frida
 import "C"
 import (
 	"runtime"
+	"sync"
 	"unsafe"
 )
 
 // Portal represents portal to collect exposed gadgets and sessions.
 type Portal struct {
 	portal *C.FridaPortalService
+
+	mu          sync.RWMutex
+	nodes       map[uint]*PortalNode
+	controllers map[uint]*PortalController
+	events      eventHub[PortalEvent]
+	handlers    []C.gulong
 }
 
 // NewPortal creates new Portal from the EndpointParameters provided.
+// Nodes and controllers are tracked from the start, see Portal.Nodes and Portal.Controllers.
 func NewPortal(clusterParams, controlParams *EndpointParameters) *Portal {
 	p := C.frida_portal_service_new(clusterParams.params, controlParams.params)
 
-	return &Portal{
+	portal := &Portal{
 		portal: p,
 	}
+	portal.track()
+	return portal
 }
 
 // Device returns portal device.
@@ -141,11 +151,13 @@ func (p *Portal) UntagConnection(connectionID uint, tag string) {
 
 // Clean will clean the resources held by the frida.
 func (p *Portal) Clean() {
+	p.untrack()
 	clean(unsafe.Pointer(p.portal), unrefFrida)
 }
 
 // On connects portal to specific signals. Once sigName is triggered,
 // fn callback will be called with parameters populated.
+// See Portal.Events for the typed events.
 //
 // Signals available are:
 //   - "node_connected" with callback as func(connId uint, addr *frida.Address) {}
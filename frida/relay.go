@@ -2,7 +2,20 @@ package frida
 
 //#include <frida-core.h>
 import "C"
-import "unsafe"
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"unsafe"
+)
+
+const (
+	defaultTurnPort  = "3478"
+	defaultTurnsPort = "5349"
+)
 
 // Relay type represents relay for setting up p2p.
 type Relay struct {
@@ -41,6 +54,104 @@ func NewRelay(address, username, password string, kind RelayKind) *Relay {
 	return &Relay{rly}
 }
 
+// ParseRelayURL creates the relay from the TURN URI as described in RFC 7065, e.g.
+// "turn:turn.example.com?transport=tcp" or "turns:turn.example.com:443". The kind is derived
+// from the scheme and the transport, the default TURN ports are used if the port is missing.
+func ParseRelayURL(rawURL, username, password string) (*Relay, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRelay, err)
+	}
+
+	hostport := u.Opaque
+	if hostport == "" {
+		// turn://host:port is not valid per RFC 7065 but commonly used
+		hostport = u.Host
+	}
+
+	var kind RelayKind
+	port := defaultTurnPort
+	transport := strings.ToLower(u.Query().Get("transport"))
+
+	switch strings.ToLower(u.Scheme) {
+	case "turn":
+		switch transport {
+		case "", "udp":
+			kind = RelayKindTurnUDP
+		case "tcp":
+			kind = RelayKindTurnTCP
+		default:
+			return nil, fmt.Errorf("%w: unsupported transport %q", ErrInvalidRelay, transport)
+		}
+	case "turns":
+		if transport != "" && transport != "tcp" {
+			return nil, fmt.Errorf("%w: unsupported transport %q for turns", ErrInvalidRelay, transport)
+		}
+		kind = RelayKindTurnTLS
+		port = defaultTurnsPort
+	default:
+		return nil, fmt.Errorf("%w: unsupported scheme %q", ErrInvalidRelay, u.Scheme)
+	}
+
+	host, port, err := splitHostPortDefault(hostport, port)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRelay, err)
+	}
+	hostport = net.JoinHostPort(host, port)
+	if err := validateRelayAddress(hostport); err != nil {
+		return nil, err
+	}
+
+	return NewRelay(hostport, username, password, kind), nil
+}
+
+// Validate checks that the relay address is in the host[:port] form and the kind is known,
+// frida uses the default TURN port if the port is missing.
+func (relay *Relay) Validate() error {
+	if relay == nil || relay.r == nil {
+		return fmt.Errorf("%w: nil relay", ErrInvalidRelay)
+	}
+	if kind := relay.RelayKind(); kind < RelayKindTurnUDP || kind > RelayKindTurnTLS {
+		return fmt.Errorf("%w: unknown kind %d", ErrInvalidRelay, kind)
+	}
+	return validateRelayAddress(relay.Address())
+}
+
+func validateRelayAddress(address string) error {
+	if address == "" {
+		return fmt.Errorf("%w: empty address", ErrInvalidRelay)
+	}
+	if lower := strings.ToLower(address); strings.HasPrefix(lower, "turn:") || strings.HasPrefix(lower, "turns:") {
+		return fmt.Errorf("%w: %s is URL, use ParseRelayURL", ErrInvalidRelay, address)
+	}
+	host, port, err := splitHostPortDefault(address, defaultTurnPort)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidRelay, err)
+	}
+	if host == "" {
+		return fmt.Errorf("%w: missing host in %s", ErrInvalidRelay, address)
+	}
+	if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+		return fmt.Errorf("%w: invalid port in %s", ErrInvalidRelay, address)
+	}
+	return nil
+}
+
+// splitHostPortDefault splits the address into the host and the port, defaultPort is returned
+// for the address without the port, both for the host name and the IP address, bracketed or not.
+func splitHostPortDefault(address, defaultPort string) (host, port string, err error) {
+	if net.ParseIP(address) != nil {
+		return address, defaultPort, nil
+	}
+
+	host, port, err = net.SplitHostPort(address)
+	var addrErr *net.AddrError
+	if errors.As(err, &addrErr) && addrErr.Err == "missing port in address" {
+		return strings.TrimSuffix(strings.TrimPrefix(address, "["), "]"), defaultPort, nil
+	}
+	return host, port, err
+}
+
 // Address returns the address of the relay.
 func (relay *Relay) Address() string {
 	return C.GoString(C.frida_relay_get_address(relay.r))
@@ -0,0 +1,133 @@
+package frida
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateRelayAddress(t *testing.T) {
+	tests := []struct {
+		address string
+		wantErr bool
+	}{
+		{address: "turn.example.com:3478"},
+		{address: "turn.example.com"},
+		{address: "10.0.0.1:443"},
+		{address: "10.0.0.1"},
+		{address: "[::1]:3478"},
+		{address: "[::1]"},
+		{address: "::1"},
+		{address: "", wantErr: true},
+		{address: ":3478", wantErr: true},
+		{address: "turn.example.com:", wantErr: true},
+		{address: "turn.example.com:0", wantErr: true},
+		{address: "turn.example.com:65536", wantErr: true},
+		{address: "turn.example.com:turn", wantErr: true},
+		{address: "a:b:c", wantErr: true},
+		{address: "[::1", wantErr: true},
+		{address: "turn:turn.example.com", wantErr: true},
+		{address: "TURNS:turn.example.com:443", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		err := validateRelayAddress(tt.address)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("validateRelayAddress(%q) error = %v, wantErr %v", tt.address, err, tt.wantErr)
+		}
+		if err != nil && !errors.Is(err, ErrInvalidRelay) {
+			t.Errorf("validateRelayAddress(%q) error = %v, want %v", tt.address, err, ErrInvalidRelay)
+		}
+	}
+}
+
+func TestParseRelayURL(t *testing.T) {
+	tests := []struct {
+		url     string
+		address string
+		kind    RelayKind
+		wantErr bool
+	}{
+		{url: "turn:turn.example.com", address: "turn.example.com:3478", kind: RelayKindTurnUDP},
+		{url: "turn:turn.example.com:3479", address: "turn.example.com:3479", kind: RelayKindTurnUDP},
+		{url: "turn:turn.example.com?transport=udp", address: "turn.example.com:3478", kind: RelayKindTurnUDP},
+		{url: "turn:turn.example.com?transport=tcp", address: "turn.example.com:3478", kind: RelayKindTurnTCP},
+		{url: "TURN:turn.example.com?transport=TCP", address: "turn.example.com:3478", kind: RelayKindTurnTCP},
+		{url: "turns:turn.example.com", address: "turn.example.com:5349", kind: RelayKindTurnTLS},
+		{url: "turns:turn.example.com:443?transport=tcp", address: "turn.example.com:443", kind: RelayKindTurnTLS},
+		{url: "turn://turn.example.com:3478", address: "turn.example.com:3478", kind: RelayKindTurnUDP},
+		{url: "turn:[::1]", address: "[::1]:3478", kind: RelayKindTurnUDP},
+		{url: "turn:10.0.0.1:443", address: "10.0.0.1:443", kind: RelayKindTurnUDP},
+		{url: "turn:turn.example.com?transport=sctp", wantErr: true},
+		{url: "turns:turn.example.com?transport=udp", wantErr: true},
+		{url: "stun:stun.example.com", wantErr: true},
+		{url: "turn.example.com:3478", wantErr: true},
+		{url: "turn:", wantErr: true},
+		{url: "turn:turn.example.com:0", wantErr: true},
+		{url: "turn:turn.example.com:port", wantErr: true},
+		{url: ":turn.example.com", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		relay, err := ParseRelayURL(tt.url, "user", "secret")
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseRelayURL(%q) error = %v, wantErr %v", tt.url, err, tt.wantErr)
+			continue
+		}
+		if err != nil {
+			if !errors.Is(err, ErrInvalidRelay) {
+				t.Errorf("ParseRelayURL(%q) error = %v, want %v", tt.url, err, ErrInvalidRelay)
+			}
+			continue
+		}
+
+		if got := relay.Address(); got != tt.address {
+			t.Errorf("ParseRelayURL(%q) address = %q, want %q", tt.url, got, tt.address)
+		}
+		if got := relay.RelayKind(); got != tt.kind {
+			t.Errorf("ParseRelayURL(%q) kind = %v, want %v", tt.url, got, tt.kind)
+		}
+		if relay.Username() != "user" || relay.Password() != "secret" {
+			t.Errorf("ParseRelayURL(%q) credentials = %q, %q", tt.url, relay.Username(), relay.Password())
+		}
+		relay.Clean()
+	}
+}
+
+func TestPeerOptionsValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		stun   string
+		relays []*Relay
+		want   error
+	}{
+		{name: "empty"},
+		{name: "stun server", stun: "stun.example.com:3478"},
+		{name: "stun server without host", stun: ":3478", want: ErrInvalidStunServer},
+		{
+			name:   "relays",
+			stun:   "stun.example.com:3478",
+			relays: []*Relay{NewRelay("turn.example.com:3478", "user", "secret", RelayKindTurnUDP), NewRelay("turn.example.com", "", "", RelayKindTurnTLS)},
+		},
+		{name: "relay with url", relays: []*Relay{NewRelay("turn:turn.example.com", "", "", RelayKindTurnUDP)}, want: ErrInvalidRelay},
+		{name: "relay with invalid port", relays: []*Relay{NewRelay("turn.example.com:99999", "", "", RelayKindTurnUDP)}, want: ErrInvalidRelay},
+	}
+
+	for _, tt := range tests {
+		opts := NewPeerOptions()
+		if tt.stun != "" {
+			opts.SetStunServer(tt.stun)
+		}
+		for _, relay := range tt.relays {
+			opts.AddRelay(relay)
+		}
+
+		if err := opts.Validate(); !errors.Is(err, tt.want) {
+			t.Errorf("%s: Validate() error = %v, want %v", tt.name, err, tt.want)
+		}
+
+		opts.Clean()
+		for _, relay := range tt.relays {
+			relay.Clean()
+		}
+	}
+}
@@ -0,0 +1,288 @@
+package frida
+
+//#include <frida-core.h>
+import "C"
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"unsafe"
+)
+
+type scriptHandler struct {
+	sigName string
+	fn      any
+}
+
+// ReloadableScript wraps the Script which can be replaced with the new version
+// of the source while keeping the signal handlers connected. Calls to the rpc.exports
+// always go to the currently loaded script.
+type ReloadableScript struct {
+	session *Session
+	config  *ScriptConfig
+	path    string
+
+	reloadMu sync.Mutex
+	mu       sync.RWMutex
+	script   *Script
+	handlers []scriptHandler
+	onReload []func(err error)
+	monitor  *FileMonitor
+	watchers []func()
+	closed   bool
+}
+
+// CreateReloadableScript creates the reloadable script from the source in the file at path.
+// Script is not loaded until Load is called, use Watch to reload it once the file changes.
+func (s *Session) CreateReloadableScript(path string, opts *ScriptOptions) (*ReloadableScript, error) {
+	source, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := s.CreateReloadableScriptFromSource(string(source), opts)
+	if err != nil {
+		return nil, err
+	}
+	r.path = path
+	return r, nil
+}
+
+// CreateReloadableScriptFromSource creates the reloadable script from the source provided.
+// Use Reload or WatchCompiler to replace the source afterwards.
+func (s *Session) CreateReloadableScriptFromSource(source string, opts *ScriptOptions) (*ReloadableScript, error) {
+	config := &ScriptConfig{Name: "frida-go"}
+	if opts != nil {
+		config = opts.Config()
+	}
+
+	sc, err := s.CreateScriptWithOptions(source, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ReloadableScript{
+		session: s,
+		config:  config,
+		script:  sc,
+	}, nil
+}
+
+// Script returns the currently active script.
+func (r *ReloadableScript) Script() *Script {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.script
+}
+
+// Load loads the current script into the process.
+func (r *ReloadableScript) Load() error {
+	r.reloadMu.Lock()
+	defer r.reloadMu.Unlock()
+
+	return r.Script().Load()
+}
+
+// Unload unloads the current script, use Reload to load the new one.
+func (r *ReloadableScript) Unload() error {
+	r.reloadMu.Lock()
+	defer r.reloadMu.Unlock()
+
+	return r.Script().Unload()
+}
+
+// Reload creates the script from the source, loads it with the signal handlers reconnected and
+// only then unloads the previous one. If the new script could not be created or loaded, the previous
+// one is kept loaded. ErrScriptClosed is returned once the reloadable script is cleaned.
+func (r *ReloadableScript) Reload(source string) error {
+	err := r.reload(source)
+	r.mu.RLock()
+	callbacks := r.onReload
+	r.mu.RUnlock()
+
+	for _, fn := range callbacks {
+		fn(err)
+	}
+	return err
+}
+
+// ReloadFromFile reloads the script with the contents of the file it was created from.
+func (r *ReloadableScript) ReloadFromFile() error {
+	if r.path == "" {
+		return errors.New("reloadable script was not created from the file")
+	}
+	source, err := os.ReadFile(r.path)
+	if err != nil {
+		return err
+	}
+	return r.Reload(string(source))
+}
+
+func (r *ReloadableScript) reload(source string) error {
+	r.reloadMu.Lock()
+	defer r.reloadMu.Unlock()
+
+	// reloads queued by the watchers may run after Clean
+	r.mu.RLock()
+	closed := r.closed
+	r.mu.RUnlock()
+	if closed {
+		return ErrScriptClosed
+	}
+
+	opts, err := NewScriptOptionsFromConfig(r.config)
+	if err != nil {
+		return err
+	}
+	defer opts.Clean()
+
+	sc, err := r.session.CreateScriptWithOptions(source, opts)
+	if err != nil {
+		return err
+	}
+
+	r.mu.RLock()
+	for _, h := range r.handlers {
+		sc.On(h.sigName, h.fn)
+	}
+	r.mu.RUnlock()
+
+	// handlers may call into the reloadable script so the lock
+	// must not be held while frida is loading or unloading
+	if err := sc.Load(); err != nil {
+		sc.Clean()
+		return err
+	}
+
+	r.mu.Lock()
+	old := r.script
+	r.script = sc
+	r.mu.Unlock()
+
+	if !old.IsDestroyed() {
+		old.Unload()
+	}
+	old.Clean()
+
+	return nil
+}
+
+// OnReload registers fn to be called after every reload with the error if reload failed.
+func (r *ReloadableScript) OnReload(fn func(err error)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.onReload = append(r.onReload, fn)
+}
+
+// Watch starts monitoring the file the script was created from and reloads
+// the script once the file changes.
+func (r *ReloadableScript) Watch() error {
+	if r.path == "" {
+		return errors.New("reloadable script was not created from the file")
+	}
+
+	r.mu.Lock()
+	if r.monitor != nil {
+		r.mu.Unlock()
+		return nil
+	}
+	mon := NewFileMonitor(r.path)
+	r.monitor = mon
+	r.mu.Unlock()
+
+	mon.On("change", func(changedFile, otherFile, changeType string) {
+		if changeType != "changes-done-hint" && changeType != "created" {
+			return
+		}
+		// signal is emitted on the frida thread, reload needs to call blocking functions
+		go r.ReloadFromFile()
+	})
+
+	return mon.Enable()
+}
+
+// WatchCompiler reloads the script with every bundle the compiler outputs.
+// Compiler.Watch needs to be called by the caller to start the compilation.
+// The returned function disconnects the script from the compiler, Clean does it as well.
+// The compiler is referenced until then, so it is safe to clean it while watching.
+func (r *ReloadableScript) WatchCompiler(c *Compiler) (stop func()) {
+	cc := c.cc
+	C.g_object_ref(C.gpointer(cc))
+	id := connectClosure(unsafe.Pointer(cc), "output", func(bundle string) {
+		go r.Reload(bundle)
+	})
+
+	var once sync.Once
+	stop = func() {
+		once.Do(func() {
+			disconnectClosure(unsafe.Pointer(cc), id)
+			clean(unsafe.Pointer(cc), unrefFrida)
+		})
+	}
+
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		stop()
+		return stop
+	}
+	r.watchers = append(r.watchers, stop)
+	r.mu.Unlock()
+	return stop
+}
+
+// On connects the script to specific signals, the handlers are reconnected
+// to the new script on every reload. See Script.On for the available signals.
+func (r *ReloadableScript) On(sigName string, fn any) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.handlers = append(r.handlers, scriptHandler{sigName, fn})
+	r.script.On(sigName, fn)
+}
+
+// Post sends post to the current script.
+func (r *ReloadableScript) Post(jsonString string, data []byte) {
+	r.Script().Post(jsonString, data)
+}
+
+// ExportsCall will try to call fn from the rpc.exports of the current script with args provided.
+func (r *ReloadableScript) ExportsCall(fn string, args ...any) any {
+	return r.Script().ExportsCall(fn, args...)
+}
+
+// ExportsCallWithContext will try to call fn from the rpc.exports of the current script
+// with args provided using context provided.
+func (r *ReloadableScript) ExportsCallWithContext(ctx context.Context, fn string, args ...any) any {
+	return r.Script().ExportsCallWithContext(ctx, fn, args...)
+}
+
+// Clean stops watching the file and the compilers and cleans the current script.
+// Reloads afterwards return ErrScriptClosed.
+func (r *ReloadableScript) Clean() {
+	r.reloadMu.Lock()
+	defer r.reloadMu.Unlock()
+
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return
+	}
+	r.closed = true
+	mon := r.monitor
+	r.monitor = nil
+	watchers := r.watchers
+	r.watchers = nil
+	sc := r.script
+	r.mu.Unlock()
+
+	for _, stop := range watchers {
+		stop()
+	}
+	if mon != nil {
+		mon.Disable()
+		mon.Clean()
+	}
+	sc.Clean()
+}
@@ -20,24 +20,42 @@ func NewRemoteDeviceOptions() *RemoteDeviceOptions {
 	}
 }
 
-// Certificate returns the certificate for the remote device options.
+// Certificate returns the certificate for the remote device options, nil if none is set.
+// The certificate holds its own reference, so it should be cleaned with Clean.
 func (r *RemoteDeviceOptions) Certificate() *Certificate {
+	if r == nil || r.opts == nil {
+		return nil
+	}
 	cert := C.frida_remote_device_options_get_certificate(r.opts)
+	if cert == nil {
+		return nil
+	}
+	C.g_object_ref(C.gpointer(cert))
 	return &Certificate{cert}
 }
 
 // Origin returns the origin for the remote device options.
 func (r *RemoteDeviceOptions) Origin() string {
+	if r == nil || r.opts == nil {
+		return ""
+	}
 	return C.GoString(C.frida_remote_device_options_get_origin(r.opts))
 }
 
 // Token returns the token for the remote device options.
 func (r *RemoteDeviceOptions) Token() string {
+	if r == nil || r.opts == nil {
+		return ""
+	}
 	return C.GoString(C.frida_remote_device_options_get_token(r.opts))
 }
 
-// KeepAliveInterval returns the keepalive interval for the remote device options.
+// KeepAliveInterval returns the keepalive interval for the remote device options,
+// -1 is returned for nil options meaning the frida default is used.
 func (r *RemoteDeviceOptions) KeepAliveInterval() int {
+	if r == nil || r.opts == nil {
+		return -1
+	}
 	return int(C.frida_remote_device_options_get_keepalive_interval(r.opts))
 }
 
@@ -48,10 +66,18 @@ func (r *RemoteDeviceOptions) SetCertificate(certPath string) error {
 		return err
 	}
 
+	defer cert.Clean()
+
 	C.frida_remote_device_options_set_certificate(r.opts, cert.cert)
 	return nil
 }
 
+// UseCertificate sets the certificate for the remote device, such as the one
+// created with CertificateFromPEM or GenerateSelfSigned.
+func (r *RemoteDeviceOptions) UseCertificate(cert *Certificate) {
+	C.frida_remote_device_options_set_certificate(r.opts, cert.cert)
+}
+
 // SetOrigin sets the origin for the remote device options.
 func (r *RemoteDeviceOptions) SetOrigin(origin string) {
 	originC := C.CString(origin)
@@ -75,7 +101,34 @@ func (r *RemoteDeviceOptions) SetKeepAlive(interval int) {
 
 // Clean will clean the resources held by the remote device options.
 func (r *RemoteDeviceOptions) Clean() {
-	clean(unsafe.Pointer(r.opts), unrefFrida)
+	if r != nil && r.opts != nil {
+		clean(unsafe.Pointer(r.opts), unrefFrida)
+	}
+}
+
+// copy returns new options holding the same values as r, nil options are copied
+// into the options holding the frida defaults.
+func (r *RemoteDeviceOptions) copy() *RemoteDeviceOptions {
+	o := NewRemoteDeviceOptions()
+	if r == nil || r.opts == nil {
+		return o
+	}
+
+	if cert := C.frida_remote_device_options_get_certificate(r.opts); cert != nil {
+		C.frida_remote_device_options_set_certificate(o.opts, cert)
+	}
+	C.frida_remote_device_options_set_origin(o.opts, C.frida_remote_device_options_get_origin(r.opts))
+	C.frida_remote_device_options_set_token(o.opts, C.frida_remote_device_options_get_token(r.opts))
+	C.frida_remote_device_options_set_keepalive_interval(o.opts, C.frida_remote_device_options_get_keepalive_interval(r.opts))
+	return o
+}
+
+// equal reports whether r and o hold the same values, both must be non nil.
+func (r *RemoteDeviceOptions) equal(o *RemoteDeviceOptions) bool {
+	return C.frida_remote_device_options_get_certificate(r.opts) == C.frida_remote_device_options_get_certificate(o.opts) &&
+		r.Origin() == o.Origin() &&
+		r.Token() == o.Token() &&
+		r.KeepAliveInterval() == o.KeepAliveInterval()
 }
 
 func gTLSCertificateFromFile(pempath string) (*Certificate, error) {
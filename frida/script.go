@@ -9,6 +9,7 @@ import (
 	"runtime"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"unsafe"
 
 	"github.com/google/uuid"
@@ -21,19 +22,61 @@ type Script struct {
 	hasHandler bool
 	sc         *C.FridaScript
 	fn         reflect.Value
+	session    *Session
+	name       string
+	sourceMap  atomic.Pointer[SourceMap]
+
+	mu       sync.RWMutex
+	closeErr error
+	handlers []C.gulong
+	pending  sync.Map
+}
+
+// acquire guards the frida call against the script being released, the caller
+// must call release once done if no error is returned. The lock is not reentrant, a pending
+// release would block the nested acquire, so methods holding it must only call the helpers that don't take it.
+func (s *Script) acquire() error {
+	s.mu.RLock()
+	if s.closeErr != nil {
+		s.mu.RUnlock()
+		return s.closeErr
+	}
+	return nil
+}
+
+func (s *Script) release() {
+	s.mu.RUnlock()
 }
 
 // IsDestroyed function returns whether the script previously loaded is destroyed (could be caused by unload)
 func (s *Script) IsDestroyed() bool {
+	if err := s.acquire(); err != nil {
+		return true
+	}
+	defer s.release()
+
 	destroyed := C.frida_script_is_destroyed(s.sc)
 	return int(destroyed) == 1
 }
 
 // Load function loads the script into the process.
 func (s *Script) Load() error {
+	s.mu.Lock()
+	if s.closeErr != nil {
+		err := s.closeErr
+		s.mu.Unlock()
+		return err
+	}
 	if !s.hasHandler {
-		s.On("message", func() {})
+		s.on("message", func() {})
+	}
+	s.mu.Unlock()
+
+	if err := s.acquire(); err != nil {
+		return err
 	}
+	defer s.release()
+
 	var err *C.GError
 	C.frida_script_load_sync(s.sc, nil, &err)
 	return handleGError(err)
@@ -41,6 +84,11 @@ func (s *Script) Load() error {
 
 // Unload function unload previously loaded script
 func (s *Script) Unload() error {
+	if err := s.acquire(); err != nil {
+		return err
+	}
+	defer s.release()
+
 	var err *C.GError
 	C.frida_script_unload_sync(s.sc, nil, &err)
 	return handleGError(err)
@@ -48,13 +96,24 @@ func (s *Script) Unload() error {
 
 // Eternalize function will keep the script loaded even after deataching from the process
 func (s *Script) Eternalize() error {
+	if err := s.acquire(); err != nil {
+		return err
+	}
+	defer s.release()
+
 	var err *C.GError
 	C.frida_script_eternalize_sync(s.sc, nil, &err)
 	return handleGError(err)
 }
 
 // Post sends post to the script.
+// Post is no-op once the script is released.
 func (s *Script) Post(jsonString string, data []byte) {
+	if err := s.acquire(); err != nil {
+		return
+	}
+	defer s.release()
+
 	jsonStringC := C.CString(jsonString)
 	defer C.free(unsafe.Pointer(jsonStringC))
 
@@ -75,6 +134,11 @@ func (s *Script) Post(jsonString string, data []byte) {
 
 // EnableDebugger function enables debugging on the port specified
 func (s *Script) EnableDebugger(port uint16) error {
+	if err := s.acquire(); err != nil {
+		return err
+	}
+	defer s.release()
+
 	var err *C.GError
 	C.frida_script_enable_debugger_sync(s.sc, C.guint16(port), nil, &err)
 
@@ -83,6 +147,11 @@ func (s *Script) EnableDebugger(port uint16) error {
 
 // DisableDebugger function disables debugging
 func (s *Script) DisableDebugger() error {
+	if err := s.acquire(); err != nil {
+		return err
+	}
+	defer s.release()
+
 	var err *C.GError
 	C.frida_script_disable_debugger_sync(s.sc, nil, &err)
 	return handleGError(err)
@@ -108,8 +177,90 @@ func (s *Script) ExportsCallWithContext(ctx context.Context, fn string, args ...
 	}
 }
 
+// SetSourceMap sets the source map of the script; once set, error messages passed to the
+// "message" handler have their fileName, lineNumber, columnNumber and stack mapped to the
+// original sources. Passing nil disables the mapping.
+func (s *Script) SetSourceMap(sm *SourceMap) {
+	s.sourceMap.Store(sm)
+}
+
+// SourceMap returns the source map set with SetSourceMap.
+func (s *Script) SourceMap() *SourceMap {
+	return s.sourceMap.Load()
+}
+
 // Clean will clean the resources held by the script.
+// Once cleaned, the script methods return ErrScriptClosed.
 func (s *Script) Clean() {
+	s.mu.RLock()
+	session := s.session
+	s.mu.RUnlock()
+
+	if session != nil {
+		session.forgetScript(s)
+	}
+	s.disconnectHandlers()
+	s.releaseWith(ErrScriptClosed)
+}
+
+// unloadForClose unloads the script unless it was already destroyed.
+func (s *Script) unloadForClose() error {
+	if err := s.acquire(); err != nil {
+		return nil
+	}
+	defer s.release()
+
+	if int(C.frida_script_is_destroyed(s.sc)) == 1 {
+		return nil
+	}
+
+	var err *C.GError
+	C.frida_script_unload_sync(s.sc, nil, &err)
+	return handleGError(err)
+}
+
+func (s *Script) disconnectHandlers() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closeErr != nil {
+		return
+	}
+	for _, id := range s.handlers {
+		disconnectClosure(unsafe.Pointer(s.sc), id)
+	}
+	s.handlers = nil
+}
+
+func (s *Script) detachFromSession() {
+	s.mu.Lock()
+	s.session = nil
+	s.mu.Unlock()
+}
+
+// releaseWith marks the script as released with reason, fails pending rpc calls and
+// unrefs the underlying script exactly once.
+func (s *Script) releaseWith(reason error) {
+	s.mu.Lock()
+	if s.closeErr != nil {
+		s.mu.Unlock()
+		return
+	}
+	s.closeErr = reason
+	s.session = nil
+	s.mu.Unlock()
+
+	s.pending.Range(func(key, value any) bool {
+		s.pending.Delete(key)
+		if callerCh, ok := rpcCalls.LoadAndDelete(key); ok {
+			select {
+			case callerCh.(chan any) <- reason:
+			default:
+			}
+		}
+		return true
+	})
+
 	clean(unsafe.Pointer(s.sc), unrefFrida)
 }
 
@@ -120,14 +271,27 @@ func (s *Script) Clean() {
 //   - "destroyed" with callback as func() {}
 //   - "message" with callback as func(message string, data []byte) {}
 func (s *Script) On(sigName string, fn any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closeErr != nil {
+		return
+	}
+	s.on(sigName, fn)
+}
+
+// on connects fn to the signal, s.mu must be held for writing.
+func (s *Script) on(sigName string, fn any) {
 	s.hasHandler = true
 	// hijack message to handle rpc calls
+	var id C.gulong
 	if sigName == "message" {
 		s.fn = reflect.ValueOf(fn)
-		connectClosure(unsafe.Pointer(s.sc), sigName, s.hijackFn)
+		id = connectClosure(unsafe.Pointer(s.sc), sigName, s.hijackFn)
 	} else {
-		connectClosure(unsafe.Pointer(s.sc), sigName, fn)
+		id = connectClosure(unsafe.Pointer(s.sc), sigName, fn)
 	}
+	s.handlers = append(s.handlers, id)
 }
 
 func getRPCIDFromMessage(message string) (string, any, error) {
@@ -166,7 +330,12 @@ func (s *Script) hijackFn(message string, data []byte) {
 		ch := callerCh.(chan any)
 		ch <- ret
 		rpcCalls.Delete(rpcID)
+		s.pending.Delete(rpcID)
 	} else {
+		if sm := s.sourceMap.Load(); sm != nil {
+			message = sm.rewriteRawMessage(message, s.name)
+		}
+
 		var args []reflect.Value
 		switch s.fn.Type().NumIn() {
 		case 1:
@@ -218,7 +387,13 @@ func (s *Script) makeExportsCall(fn string, args ...any) chan any {
 	}
 
 	ch := getChannel()
+	if err := s.acquire(); err != nil {
+		ch <- err
+		return ch
+	}
 	rpcCalls.Store(rpcData[1], ch)
+	s.pending.Store(rpcData[1], struct{}{})
+	s.release()
 
 	bt, _ := json.Marshal(rpc)
 	s.Post(string(bt), nil)
@@ -3,15 +3,65 @@ package frida
 //#include <frida-core.h>
 import "C"
 import (
+	"errors"
+	"fmt"
 	"runtime"
 	"unsafe"
 )
 
 // ScriptOptions type represents options passed to the session to create script.
+// The same options can be used to create multiple scripts, caller is responsible
+// for calling Clean once done.
 type ScriptOptions struct {
 	opts *C.FridaScriptOptions
 }
 
+// ScriptConfig is the go value representation of ScriptOptions.
+type ScriptConfig struct {
+	Name              string
+	Snapshot          []byte
+	SnapshotTransport SnapshotTransport
+	Runtime           ScriptRuntime
+}
+
+// Validate checks whether the config describes the options frida can create the script with.
+func (c *ScriptConfig) Validate() error {
+	if c.Runtime < ScriptRuntimeDefault || c.Runtime > ScriptRuntimeV8 {
+		return fmt.Errorf("invalid script runtime %d", int(c.Runtime))
+	}
+	if c.SnapshotTransport < SnapshotTransportInline || c.SnapshotTransport > SnapshotTransportSharedMemory {
+		return fmt.Errorf("invalid snapshot transport %d", int(c.SnapshotTransport))
+	}
+	if len(c.Snapshot) > 0 && c.Runtime == ScriptRuntimeQJS {
+		return errors.New("snapshots are only supported by the v8 runtime")
+	}
+	if len(c.Snapshot) == 0 && c.SnapshotTransport == SnapshotTransportSharedMemory {
+		return errors.New("snapshot transport is set but there is no snapshot")
+	}
+	return nil
+}
+
+// NewScriptOptionsFromConfig validates the config and creates new script options from it.
+func NewScriptOptionsFromConfig(cfg *ScriptConfig) (*ScriptOptions, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	name := cfg.Name
+	if name == "" {
+		name = "frida-go"
+	}
+
+	opts := NewScriptOptions(name)
+	if len(cfg.Snapshot) > 0 {
+		opts.SetSnapshot(cfg.Snapshot)
+	}
+	opts.SetSnapshotTransport(cfg.SnapshotTransport)
+	opts.SetRuntime(cfg.Runtime)
+
+	return opts, nil
+}
+
 // NewScriptOptions creates new script options with the script name provided.
 func NewScriptOptions(name string) *ScriptOptions {
 	opts := C.frida_script_options_new()
@@ -67,10 +117,9 @@ func (s *ScriptOptions) Name() string {
 
 // Snapshot returns the snapshot for the script.
 func (s *ScriptOptions) Snapshot() []byte {
+	// snapshot is owned by the options so it must not be unrefed here
 	snap := C.frida_script_options_get_snapshot(s.opts)
-	bts := getGBytes(snap)
-	clean(unsafe.Pointer(snap), unrefGObject)
-	return bts
+	return getGBytes(snap)
 }
 
 // SnapshotTransport returns the transport for the script.
@@ -79,6 +128,27 @@ func (s *ScriptOptions) SnapshotTransport() SnapshotTransport {
 	return SnapshotTransport(tr)
 }
 
+// Runtime returns the runtime for the script.
+func (s *ScriptOptions) Runtime() ScriptRuntime {
+	rt := C.frida_script_options_get_runtime(s.opts)
+	return ScriptRuntime(rt)
+}
+
+// Config returns the options as ScriptConfig.
+func (s *ScriptOptions) Config() *ScriptConfig {
+	return &ScriptConfig{
+		Name:              s.Name(),
+		Snapshot:          s.Snapshot(),
+		SnapshotTransport: s.SnapshotTransport(),
+		Runtime:           s.Runtime(),
+	}
+}
+
+// Validate checks whether the options are valid, see ScriptConfig.Validate.
+func (s *ScriptOptions) Validate() error {
+	return s.Config().Validate()
+}
+
 // Clean will clean the resources held by the script options.
 func (s *ScriptOptions) Clean() {
 	clean(unsafe.Pointer(s.opts), unrefFrida)
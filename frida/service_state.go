@@ -0,0 +1,57 @@
+package frida
+
+import (
+	"fmt"
+	"sync"
+)
+
+// serviceState tracks whether the service such as ControlService or WebGateway is running.
+// Start and Stop are serialized so that the running check and the state change are atomic.
+type serviceState struct {
+	name string
+
+	opMu    sync.Mutex
+	mu      sync.Mutex
+	running bool
+}
+
+func (s *serviceState) isRunning() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.running
+}
+
+// start runs fn if the service is not running yet and marks it as running if fn succeeds.
+// emit is called with the result of fn before the next start or stop can proceed.
+func (s *serviceState) start(fn func() error, emit func(err error)) error {
+	return s.transition(true, fn, emit)
+}
+
+// stop runs fn if the service is running and marks it as stopped if fn succeeds.
+// emit is called with the result of fn before the next start or stop can proceed.
+func (s *serviceState) stop(fn func() error, emit func(err error)) error {
+	return s.transition(false, fn, emit)
+}
+
+func (s *serviceState) transition(running bool, fn func() error, emit func(err error)) error {
+	s.opMu.Lock()
+	defer s.opMu.Unlock()
+
+	if s.isRunning() == running {
+		if running {
+			return fmt.Errorf("%s is already running", s.name)
+		}
+		return fmt.Errorf("%s is not running", s.name)
+	}
+
+	if err := fn(); err != nil {
+		emit(err)
+		return err
+	}
+
+	s.mu.Lock()
+	s.running = running
+	s.mu.Unlock()
+	emit(nil)
+	return nil
+}
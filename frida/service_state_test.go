@@ -0,0 +1,60 @@
+package frida
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestServiceState(t *testing.T) {
+	errFailed := errors.New("failed")
+
+	tests := []struct {
+		name        string
+		start       bool
+		fnErr       error
+		wantErr     bool
+		wantEmit    error
+		wantRunning bool
+	}{
+		{name: "stop stopped", start: false, wantErr: true},
+		{name: "start failure", start: true, fnErr: errFailed, wantErr: true, wantEmit: errFailed},
+		{name: "start", start: true, wantRunning: true},
+		{name: "start running", start: true, wantErr: true, wantRunning: true},
+		{name: "stop failure", start: false, fnErr: errFailed, wantErr: true, wantEmit: errFailed, wantRunning: true},
+		{name: "stop", start: false},
+	}
+
+	s := serviceState{name: "test service"}
+	for _, tt := range tests {
+		called, emitted := false, false
+		var emitErr error
+		fn := func() error {
+			called = true
+			return tt.fnErr
+		}
+		emit := func(err error) {
+			emitted, emitErr = true, err
+		}
+
+		var err error
+		if tt.start {
+			err = s.start(fn, emit)
+		} else {
+			err = s.stop(fn, emit)
+		}
+
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+		// fn and emit are skipped when the service already is in the state
+		if wantCalled := tt.fnErr != nil || !tt.wantErr; called != wantCalled || emitted != wantCalled {
+			t.Errorf("%s: fn called = %v, emit called = %v, want %v", tt.name, called, emitted, wantCalled)
+		}
+		if emitErr != tt.wantEmit {
+			t.Errorf("%s: emitted error = %v, want %v", tt.name, emitErr, tt.wantEmit)
+		}
+		if running := s.isRunning(); running != tt.wantRunning {
+			t.Errorf("%s: isRunning() = %v, want %v", tt.name, running, tt.wantRunning)
+		}
+	}
+}
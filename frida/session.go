@@ -4,17 +4,57 @@ package frida
 import "C"
 import (
 	"context"
+	"os"
 	"runtime"
+	"sync"
 	"unsafe"
 )
 
+// DetachEvent is passed to the Session.OnDetached callback.
+// Crash is populated only when the session was detached because of the process crash.
+type DetachEvent struct {
+	Reason SessionDetachReason
+	Crash  *CrashInfo
+}
+
 // Session type represents the session with the device.
+// Scripts created on the session are tracked by it so that Close can
+// tear everything down in the right order.
 type Session struct {
-	s *C.FridaSession
+	s              *C.FridaSession
+	persistTimeout uint
+
+	closeMu  sync.RWMutex
+	closed   bool
+	mu       sync.Mutex
+	scripts  []*Script
+	handlers []C.gulong
+}
+
+// acquire guards the frida call against the concurrent Close, the caller
+// must call release once done if no error is returned. The lock is not reentrant, a pending
+// Close would block the nested acquire, so methods holding it must only call the helpers that don't take it.
+func (s *Session) acquire() error {
+	s.closeMu.RLock()
+	if s.closed {
+		s.closeMu.RUnlock()
+		return ErrSessionClosed
+	}
+	return nil
+}
+
+func (s *Session) release() {
+	s.closeMu.RUnlock()
 }
 
 // IsDetached returns bool whether session is detached or not.
+// Closed session is always reported as detached.
 func (s *Session) IsDetached() bool {
+	if err := s.acquire(); err != nil {
+		return true
+	}
+	defer s.release()
+
 	detached := C.frida_session_is_detached(s.s)
 	return int(detached) == 1
 }
@@ -31,6 +71,11 @@ func (s *Session) DetachWithContext(ctx context.Context) error {
 
 // Detach detaches the current session.
 func (s *Session) Detach(opts ...OptFunc) error {
+	if err := s.acquire(); err != nil {
+		return err
+	}
+	defer s.release()
+
 	o := setupOptions(opts)
 	return s.detach(o)
 }
@@ -42,15 +87,46 @@ func (s *Session) detach(opts options) error {
 	return handleGError(err)
 }
 
+// ResumeWithContext runs Resume but with context.
+// This function will properly handle cancelling the frida operation.
+// It is advised to use this rather than handling Cancellable yourself.
+func (s *Session) ResumeWithContext(ctx context.Context) error {
+	_, err := handleWithContext(ctx, func(c *Cancellable, done chan any, errC chan error) {
+		errC <- s.Resume(WithCancel(c))
+	})
+	return err
+}
+
 // Resume resumes the current session.
-func (s *Session) Resume() error {
+func (s *Session) Resume(opts ...OptFunc) error {
+	if err := s.acquire(); err != nil {
+		return err
+	}
+	defer s.release()
+
+	o := setupOptions(opts)
+	return s.resume(o)
+}
+
+func (s *Session) resume(opts options) error {
 	var err *C.GError
-	C.frida_session_resume_sync(s.s, nil, &err)
+	C.frida_session_resume_sync(s.s, opts.cancellable, &err)
 	return handleGError(err)
 }
 
+// PersistTimeout returns the persist timeout in seconds the session was attached with.
+// Zero means the session is not persistent and can't be resumed.
+func (s *Session) PersistTimeout() uint {
+	return s.persistTimeout
+}
+
 // EnableChildGating enables child gating on the session.
 func (s *Session) EnableChildGating() error {
+	if err := s.acquire(); err != nil {
+		return err
+	}
+	defer s.release()
+
 	var err *C.GError
 	C.frida_session_enable_child_gating_sync(s.s, nil, &err)
 
@@ -59,6 +135,11 @@ func (s *Session) EnableChildGating() error {
 
 // DisableChildGating disables child gating on the session.
 func (s *Session) DisableChildGating() error {
+	if err := s.acquire(); err != nil {
+		return err
+	}
+	defer s.release()
+
 	var err *C.GError
 	C.frida_session_disable_child_gating_sync(s.s, nil, &err)
 
@@ -72,6 +153,11 @@ func (s *Session) CreateScript(script string) (*Script, error) {
 
 // CreateScriptBytes is a wrapper around CreateScript(script string)
 func (s *Session) CreateScriptBytes(script []byte, opts *ScriptOptions) (*Script, error) {
+	if err := s.acquire(); err != nil {
+		return nil, err
+	}
+	defer s.release()
+
 	bts := goBytesToGBytes(script)
 	wrapper := &GBytesWrapper{ptr: bts}
 
@@ -80,10 +166,11 @@ func (s *Session) CreateScriptBytes(script []byte, opts *ScriptOptions) (*Script
 		w.ptr = nil
 	})
 
+	// options passed by the caller stay owned by the caller so they can be reused
 	if opts == nil {
 		opts = NewScriptOptions("frida-go")
+		defer opts.Clean()
 	}
-	defer clean(unsafe.Pointer(opts.opts), unrefFrida)
 
 	var err *C.GError
 	sc := C.frida_session_create_script_from_bytes_sync(s.s,
@@ -94,48 +181,79 @@ func (s *Session) CreateScriptBytes(script []byte, opts *ScriptOptions) (*Script
 
 	runtime.KeepAlive(wrapper)
 
-	return &Script{
-		sc: sc,
-	}, handleGError(err)
+	return s.trackScript(sc, opts.Name()), handleGError(err)
 }
 
+// CreateScriptWithSnapshot creates the script using the snapshot previously created with SnapshotScript.
 func (s *Session) CreateScriptWithSnapshot(script string, snapshot []byte) (*Script, error) {
 	opts := NewScriptOptions("frida-go")
+	defer opts.Clean()
+
 	opts.SetSnapshot(snapshot)
 	return s.CreateScriptWithOptions(script, opts)
 }
 
 // CreateScriptWithOptions creates the script with the script options provided.
 // Useful in cases where you previously created the snapshot.
+// The options are not cleaned so the same options can be used for multiple scripts.
 func (s *Session) CreateScriptWithOptions(script string, opts *ScriptOptions) (*Script, error) {
+	if err := s.acquire(); err != nil {
+		return nil, err
+	}
+	defer s.release()
+
 	sc := C.CString(script)
 	defer C.free(unsafe.Pointer(sc))
 
-	if opts == nil {
-		opts = NewScriptOptions("frida-go")
-	}
-	defer clean(unsafe.Pointer(opts.opts), unrefFrida)
-
-	if opts.Name() == "" {
-		opts.SetName("frida-go")
+	// options passed by the caller stay owned by the caller so they can be reused,
+	// the default name is set on the copy
+	name := "frida-go"
+	switch {
+	case opts == nil:
+		opts = NewScriptOptions(name)
+		defer opts.Clean()
+	case opts.Name() == "":
+		config := opts.Config()
+		config.Name = name
+		named, err := NewScriptOptionsFromConfig(config)
+		if err != nil {
+			return nil, err
+		}
+		defer named.Clean()
+		opts = named
+	default:
+		name = opts.Name()
 	}
 
 	var err *C.GError
 	cScript := C.frida_session_create_script_sync(s.s, sc, opts.opts, nil, &err)
-	return &Script{
-		sc: cScript,
-	}, handleGError(err)
+	return s.trackScript(cScript, name), handleGError(err)
+}
+
+// CreateScriptFromFile creates new script from the source in the file at path.
+func (s *Session) CreateScriptFromFile(path string, opts *ScriptOptions) (*Script, error) {
+	source, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return s.CreateScriptWithOptions(string(source), opts)
 }
 
 // CompileScript compiles the script from the script as string provided.
 func (s *Session) CompileScript(script string, opts *ScriptOptions) ([]byte, error) {
+	if err := s.acquire(); err != nil {
+		return nil, err
+	}
+	defer s.release()
+
 	scriptC := C.CString(script)
 	defer C.free(unsafe.Pointer(scriptC))
 
+	// options passed by the caller stay owned by the caller so they can be reused
 	if opts == nil {
 		opts = NewScriptOptions("frida-go")
+		defer opts.Clean()
 	}
-	defer clean(unsafe.Pointer(opts.opts), unrefFrida)
 
 	var err *C.GError
 	bts := C.frida_session_compile_script_sync(s.s,
@@ -152,6 +270,11 @@ func (s *Session) CompileScript(script string, opts *ScriptOptions) ([]byte, err
 
 // SnapshotScript creates snapshot from the script.
 func (s *Session) SnapshotScript(embedScript string, snapshotOpts *SnapshotOptions) ([]byte, error) {
+	if err := s.acquire(); err != nil {
+		return nil, err
+	}
+	defer s.release()
+
 	embedScriptC := C.CString(embedScript)
 	defer C.free(unsafe.Pointer(embedScriptC))
 
@@ -169,34 +292,221 @@ func (s *Session) SnapshotScript(embedScript string, snapshotOpts *SnapshotOptio
 	return getGBytes(ret), nil
 }
 
-// SetupPeerConnection sets up peer (p2p) connection with peer options provided.
-func (s *Session) SetupPeerConnection(opts *PeerOptions) error {
-	var err *C.GError
-	C.frida_session_setup_peer_connection_sync(s.s, opts.opts, nil, &err)
-	return handleGError(err)
+// JoinPortal joins portal at the address with portal options provided, opts can be nil.
+// The membership keeps the reference to the session and opts so that it can join the portal again,
+// see PortalMembership.EnableAutoRejoin.
+func (s *Session) JoinPortal(address string, opts *PortalOptions) (*PortalMembership, error) {
+	mem, err := s.joinPortal(address, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PortalMembership{
+		mem:     mem,
+		session: s,
+		address: address,
+		opts:    opts,
+		state:   MembershipJoined,
+	}, nil
 }
 
-// JoinPortal joins portal at the address with portal options provided.
-func (s *Session) JoinPortal(address string, opts *PortalOptions) (*PortalMembership, error) {
+func (s *Session) joinPortal(address string, opts *PortalOptions) (*C.FridaPortalMembership, error) {
+	if err := s.acquire(); err != nil {
+		return nil, err
+	}
+	defer s.release()
+
 	addrC := C.CString(address)
 	defer C.free(unsafe.Pointer(addrC))
 
+	var o *C.FridaPortalOptions = nil
+	if opts != nil {
+		o = opts.opts
+	}
+
 	var err *C.GError
-	mem := C.frida_session_join_portal_sync(s.s, addrC, opts.opts, nil, &err)
+	mem := C.frida_session_join_portal_sync(s.s, addrC, o, nil, &err)
+	return mem, handleGError(err)
+}
 
-	return &PortalMembership{mem}, handleGError(err)
+// Scripts returns the scripts created on the session that were not cleaned yet.
+func (s *Session) Scripts() []*Script {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	scripts := make([]*Script, len(s.scripts))
+	copy(scripts, s.scripts)
+	return scripts
+}
+
+// Close unloads all the scripts created on the session, disconnects the signal handlers,
+// detaches and cleans the session and its scripts.
+// Close is safe to call multiple times, only the first call does the teardown; afterwards
+// session and script methods return ErrSessionClosed.
+func (s *Session) Close(ctx context.Context) error {
+	s.closeMu.Lock()
+	if s.closed {
+		s.closeMu.Unlock()
+		return nil
+	}
+	s.closed = true
+	s.closeMu.Unlock()
+
+	s.mu.Lock()
+	scripts := s.scripts
+	handlers := s.handlers
+	s.scripts = nil
+	s.handlers = nil
+	s.mu.Unlock()
+
+	var firstErr error
+	for _, sc := range scripts {
+		if err := sc.unloadForClose(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	for _, sc := range scripts {
+		sc.disconnectHandlers()
+	}
+	for _, id := range handlers {
+		disconnectClosure(unsafe.Pointer(s.s), id)
+	}
+
+	if int(C.frida_session_is_detached(s.s)) != 1 {
+		_, err := handleWithContext(ctx, func(c *Cancellable, done chan any, errC chan error) {
+			errC <- s.detach(options{cancellable: c.cancellable})
+		})
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	for _, sc := range scripts {
+		sc.releaseWith(ErrSessionClosed)
+	}
+	clean(unsafe.Pointer(s.s), unrefFrida)
+
+	return firstErr
 }
 
 // Clean will clean the resources held by the session.
+// Scripts created on the session are left for the caller to clean, use Close
+// to release everything at once.
 func (s *Session) Clean() {
+	s.closeMu.Lock()
+	if s.closed {
+		s.closeMu.Unlock()
+		return
+	}
+	s.closed = true
+	s.closeMu.Unlock()
+
+	s.mu.Lock()
+	scripts := s.scripts
+	handlers := s.handlers
+	s.scripts = nil
+	s.handlers = nil
+	s.mu.Unlock()
+
+	for _, sc := range scripts {
+		sc.detachFromSession()
+	}
+	for _, id := range handlers {
+		disconnectClosure(unsafe.Pointer(s.s), id)
+	}
 	clean(unsafe.Pointer(s.s), unrefFrida)
 }
 
+func (s *Session) trackScript(sc *C.FridaScript, name string) *Script {
+	if sc == nil {
+		return &Script{}
+	}
+
+	script := &Script{
+		sc:      sc,
+		session: s,
+		name:    name,
+	}
+
+	s.mu.Lock()
+	s.scripts = append(s.scripts, script)
+	s.mu.Unlock()
+
+	return script
+}
+
+func (s *Session) forgetScript(script *Script) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, sc := range s.scripts {
+		if sc == script {
+			s.scripts = append(s.scripts[:i], s.scripts[i+1:]...)
+			return
+		}
+	}
+}
+
+// OnDetached connects fn to the "detached" signal, passing the reason and the
+// crash details as go values.
+func (s *Session) OnDetached(fn func(ev DetachEvent)) {
+	s.On("detached", func(reason SessionDetachReason, crash *Crash) {
+		fn(DetachEvent{
+			Reason: reason,
+			Crash:  crash.Info(),
+		})
+	})
+}
+
 // On connects session to specific signals. Once sigName is triggered,
 // fn callback will be called with parameters populated.
 //
 // Signals available are:
 //   - "detached" with callback as func(reason frida.SessionDetachReason, crash *frida.Crash) {}
 func (s *Session) On(sigName string, fn any) {
-	connectClosure(unsafe.Pointer(s.s), sigName, fn)
+	s.connect(sigName, fn)
+}
+
+// connect connects fn to the signal and returns the handler id which can be passed
+// to disconnect, 0 is returned if the session is closed.
+func (s *Session) connect(sigName string, fn any) C.gulong {
+	if err := s.acquire(); err != nil {
+		return 0
+	}
+	defer s.release()
+
+	id := connectClosure(unsafe.Pointer(s.s), sigName, fn)
+
+	s.mu.Lock()
+	s.handlers = append(s.handlers, id)
+	s.mu.Unlock()
+	return id
+}
+
+// disconnect disconnects the handler connected with connect, the handlers of the
+// closed session are already disconnected by Close.
+func (s *Session) disconnect(id C.gulong) {
+	if id == 0 {
+		return
+	}
+	if err := s.acquire(); err != nil {
+		return
+	}
+	defer s.release()
+
+	s.mu.Lock()
+	found := false
+	for i, h := range s.handlers {
+		if h == id {
+			s.handlers = append(s.handlers[:i], s.handlers[i+1:]...)
+			found = true
+			break
+		}
+	}
+	s.mu.Unlock()
+
+	if found {
+		disconnectClosure(unsafe.Pointer(s.s), id)
+	}
 }
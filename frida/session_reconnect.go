@@ -0,0 +1,224 @@
+package frida
+
+//#include <frida-core.h>
+import "C"
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+const (
+	defaultReconnectInitialBackoff = 500 * time.Millisecond
+	defaultReconnectMaxBackoff     = 10 * time.Second
+)
+
+// ErrSessionNotPersistent is returned when auto reconnect is requested for the
+// session that was attached without persist timeout.
+var ErrSessionNotPersistent = errors.New("session is not persistent, attach with NewSessionOptions(realm, persistTimeout)")
+
+// ReconnectEventType represents the state change reported by the SessionReconnector.
+type ReconnectEventType int
+
+const (
+	ReconnectEventInterrupted ReconnectEventType = iota
+	ReconnectEventResumed
+	ReconnectEventLost
+)
+
+func (r ReconnectEventType) String() string {
+	return [...]string{"interrupted",
+		"resumed",
+		"lost"}[r]
+}
+
+// ReconnectEvent is passed to the ReconnectOptions.OnEvent callback.
+type ReconnectEvent struct {
+	Type    ReconnectEventType
+	Reason  SessionDetachReason
+	Attempt int   // number of Resume attempts made, populated for resumed and lost events
+	Err     error // last Resume error, populated for lost events
+}
+
+// ReconnectOptions configures the automatic reconnect of the session.
+// Zero values are replaced with the defaults; PersistTimeout defaults to the
+// persist timeout the session was attached with.
+type ReconnectOptions struct {
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	PersistTimeout time.Duration
+	OnEvent        func(ev ReconnectEvent)
+}
+
+// SessionReconnector resumes the persistent session once its connection
+// gets interrupted. Scripts created on the session stay valid across
+// the interruption as the underlying session is resumed rather than recreated.
+type SessionReconnector struct {
+	session      *Session
+	opts         ReconnectOptions
+	ctx          context.Context
+	cancel       context.CancelFunc
+	handler      C.gulong
+	mu           sync.Mutex
+	reconnecting bool
+	stopped      bool
+}
+
+// EnableAutoReconnect starts supervising the session, calling Resume with exponential
+// backoff whenever the connection gets terminated and the persist window did not elapse.
+//
+// Example:
+//
+//	opts := frida.NewSessionOptions(frida.RealmNative, 30)
+//	session, err := device.Attach(pid, opts)
+//	// ...
+//	rc, err := session.EnableAutoReconnect(&frida.ReconnectOptions{
+//		OnEvent: func(ev frida.ReconnectEvent) {
+//			fmt.Println("session", ev.Type)
+//		},
+//	})
+//	// ...
+//	rc.Stop()
+func (s *Session) EnableAutoReconnect(opts *ReconnectOptions) (*SessionReconnector, error) {
+	var o ReconnectOptions
+	if opts != nil {
+		o = *opts
+	}
+
+	if o.InitialBackoff <= 0 {
+		o.InitialBackoff = defaultReconnectInitialBackoff
+	}
+	if o.MaxBackoff <= 0 {
+		o.MaxBackoff = defaultReconnectMaxBackoff
+	}
+	if o.MaxBackoff < o.InitialBackoff {
+		o.MaxBackoff = o.InitialBackoff
+	}
+	if o.PersistTimeout <= 0 {
+		o.PersistTimeout = time.Duration(s.persistTimeout) * time.Second
+	}
+	if o.PersistTimeout <= 0 {
+		return nil, ErrSessionNotPersistent
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &SessionReconnector{
+		session: s,
+		opts:    o,
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+
+	r.handler = s.connect("detached", r.onDetached)
+
+	return r, nil
+}
+
+// IsReconnecting returns whether the reconnector is currently trying to resume the session.
+func (r *SessionReconnector) IsReconnecting() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.reconnecting
+}
+
+// Stop stops supervising the session and disconnects the "detached" handler,
+// any reconnect in progress is cancelled.
+func (r *SessionReconnector) Stop() {
+	r.mu.Lock()
+	if r.stopped {
+		r.mu.Unlock()
+		return
+	}
+	r.stopped = true
+	r.mu.Unlock()
+
+	r.cancel()
+	r.session.disconnect(r.handler)
+}
+
+func (r *SessionReconnector) onDetached(reason SessionDetachReason, crash *Crash) {
+	r.mu.Lock()
+	if r.stopped || r.reconnecting {
+		r.mu.Unlock()
+		return
+	}
+
+	// signal is emitted on the frida thread so blocking calls
+	// such as Resume need to happen in another goroutine
+	if !reason.IsRecoverable() {
+		r.mu.Unlock()
+		go r.emit(ReconnectEvent{Type: ReconnectEventLost, Reason: reason})
+		return
+	}
+
+	r.reconnecting = true
+	r.mu.Unlock()
+
+	go r.reconnect(reason)
+}
+
+func (r *SessionReconnector) reconnect(reason SessionDetachReason) {
+	defer func() {
+		r.mu.Lock()
+		r.reconnecting = false
+		r.mu.Unlock()
+	}()
+
+	r.emit(ReconnectEvent{Type: ReconnectEventInterrupted, Reason: reason})
+
+	deadline := time.Now().Add(r.opts.PersistTimeout)
+	backoff := r.opts.InitialBackoff
+
+	var lastErr error
+	attempt := 0
+	for {
+		attempt++
+
+		ctx, cancel := context.WithDeadline(r.ctx, deadline)
+		err := r.session.ResumeWithContext(ctx)
+		cancel()
+
+		if err == nil {
+			r.emit(ReconnectEvent{
+				Type:    ReconnectEventResumed,
+				Reason:  reason,
+				Attempt: attempt,
+			})
+			return
+		}
+		lastErr = err
+
+		if r.ctx.Err() != nil {
+			return
+		}
+
+		if time.Until(deadline) < backoff {
+			break
+		}
+
+		select {
+		case <-r.ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > r.opts.MaxBackoff {
+			backoff = r.opts.MaxBackoff
+		}
+	}
+
+	r.emit(ReconnectEvent{
+		Type:    ReconnectEventLost,
+		Reason:  reason,
+		Attempt: attempt,
+		Err:     lastErr,
+	})
+}
+
+func (r *SessionReconnector) emit(ev ReconnectEvent) {
+	if r.opts.OnEvent != nil {
+		r.opts.OnEvent(ev)
+	}
+}
@@ -0,0 +1,339 @@
+package frida
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// SnapshotKey identifies the snapshot created by Session.SnapshotScript.
+// Snapshots are only valid for the same frida version, so Version is part of the key.
+type SnapshotKey struct {
+	EmbedHash  string
+	WarmupHash string
+	Runtime    ScriptRuntime
+	Version    string
+	// Target optionally distinguishes the snapshots for different architectures/platforms
+	// when sessions to different targets share the cache.
+	Target string
+}
+
+// NewSnapshotKey creates the key for the scripts and runtime provided using the current frida version.
+func NewSnapshotKey(embedScript, warmupScript string, rt ScriptRuntime) SnapshotKey {
+	return SnapshotKey{
+		EmbedHash:  hashScript(embedScript),
+		WarmupHash: hashScript(warmupScript),
+		Runtime:    rt,
+		Version:    Version(),
+	}
+}
+
+// String returns the hex digest identifying the key.
+func (k SnapshotKey) String() string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00%s\x00%s\x00%s", k.EmbedHash, k.WarmupHash, k.Runtime, k.Version, k.Target)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func hashScript(script string) string {
+	sum := sha256.Sum256([]byte(script))
+	return hex.EncodeToString(sum[:])
+}
+
+// SnapshotStore is the backend used by the SnapshotCache to keep snapshots.
+type SnapshotStore interface {
+	// Get returns the snapshot for the key, ok is false if there is no such snapshot.
+	Get(key SnapshotKey) (snapshot []byte, ok bool, err error)
+	// Put stores the snapshot for the key.
+	Put(key SnapshotKey, snapshot []byte) error
+}
+
+// MemorySnapshotStore keeps the snapshots in memory.
+type MemorySnapshotStore struct {
+	mu        sync.RWMutex
+	snapshots map[string][]byte
+}
+
+// NewMemorySnapshotStore creates new empty in-memory store.
+func NewMemorySnapshotStore() *MemorySnapshotStore {
+	return &MemorySnapshotStore{
+		snapshots: make(map[string][]byte),
+	}
+}
+
+// Get returns the snapshot for the key.
+func (m *MemorySnapshotStore) Get(key SnapshotKey) ([]byte, bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	snapshot, ok := m.snapshots[key.String()]
+	return snapshot, ok, nil
+}
+
+// Put stores the snapshot for the key.
+func (m *MemorySnapshotStore) Put(key SnapshotKey, snapshot []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.snapshots[key.String()] = snapshot
+	return nil
+}
+
+// DirSnapshotStore keeps the snapshots as files inside the directory, grouped
+// by frida version.
+type DirSnapshotStore struct {
+	dir string
+}
+
+// NewDirSnapshotStore creates the store inside dir, creating it if needed.
+// Snapshots created by other frida versions can't be used anymore, call Prune to remove them.
+func NewDirSnapshotStore(dir string) (*DirSnapshotStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &DirSnapshotStore{dir: dir}, nil
+}
+
+// Get returns the snapshot for the key.
+func (d *DirSnapshotStore) Get(key SnapshotKey) ([]byte, bool, error) {
+	snapshot, err := os.ReadFile(d.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return snapshot, true, nil
+}
+
+// Put stores the snapshot for the key.
+func (d *DirSnapshotStore) Put(key SnapshotKey, snapshot []byte) error {
+	pth := d.path(key)
+	if err := os.MkdirAll(filepath.Dir(pth), 0o755); err != nil {
+		return err
+	}
+
+	// write to temporary file first so that readers never see partial snapshot
+	tmp, err := os.CreateTemp(filepath.Dir(pth), ".snapshot-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(snapshot); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), pth)
+}
+
+// Prune removes the snapshots created by frida versions other than version, usually Version().
+// Only the "frida-<version>" directories holding nothing but snapshots are removed, so the
+// store can live in the directory shared with other data.
+func (d *DirSnapshotStore) Prune(version string) error {
+	entries, err := os.ReadDir(d.dir)
+	if err != nil {
+		return err
+	}
+
+	current := versionDirName(version)
+	for _, entry := range entries {
+		if !entry.IsDir() || entry.Name() == current || !strings.HasPrefix(entry.Name(), "frida-") {
+			continue
+		}
+
+		pth := filepath.Join(d.dir, entry.Name())
+		ok, err := isSnapshotVersionDir(pth)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		if err := os.RemoveAll(pth); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isSnapshotVersionDir returns whether the directory contains only the snapshots
+// and the temporary files written by Put.
+func isSnapshotVersionDir(dir string) (bool, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return false, err
+	}
+
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			return false, nil
+		}
+		name := entry.Name()
+		if strings.HasPrefix(name, ".snapshot-") {
+			continue
+		}
+		digest := strings.TrimSuffix(name, ".snapshot")
+		if digest == name || len(digest) != sha256.Size*2 {
+			return false, nil
+		}
+		if _, err := hex.DecodeString(digest); err != nil {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func (d *DirSnapshotStore) path(key SnapshotKey) string {
+	return filepath.Join(d.dir, versionDirName(key.Version), key.String()+".snapshot")
+}
+
+func versionDirName(version string) string {
+	return "frida-" + strings.NewReplacer("/", "_", "\\", "_", "..", "_").Replace(version)
+}
+
+type snapshotCall struct {
+	wg       sync.WaitGroup
+	snapshot []byte
+	err      error
+}
+
+// SnapshotCache creates the snapshots on the first use and serves them from the store afterwards.
+// Concurrent requests for the same snapshot create it only once.
+type SnapshotCache struct {
+	store    SnapshotStore
+	mu       sync.Mutex
+	inflight map[string]*snapshotCall
+}
+
+// NewSnapshotCache creates the cache backed by the store; in-memory store is used if store is nil.
+func NewSnapshotCache(store SnapshotStore) *SnapshotCache {
+	if store == nil {
+		store = NewMemorySnapshotStore()
+	}
+	return &SnapshotCache{
+		store:    store,
+		inflight: make(map[string]*snapshotCall),
+	}
+}
+
+// Snapshot returns the snapshot for the key, creating it with the session if it's not in the store yet.
+func (c *SnapshotCache) Snapshot(session *Session, key SnapshotKey, embedScript, warmupScript string) ([]byte, error) {
+	return c.snapshot(key, func() ([]byte, error) {
+		snapOpts := NewSnapshotOptions(warmupScript, key.Runtime)
+		defer snapOpts.Clean()
+
+		return session.SnapshotScript(embedScript, snapOpts)
+	})
+}
+
+// snapshot returns the snapshot for the key from the store, calling create once for the
+// concurrent requests of the missing snapshot.
+func (c *SnapshotCache) snapshot(key SnapshotKey, create func() ([]byte, error)) ([]byte, error) {
+	if snapshot, ok, err := c.store.Get(key); err != nil {
+		return nil, err
+	} else if ok {
+		return snapshot, nil
+	}
+
+	k := key.String()
+
+	c.mu.Lock()
+	if call, ok := c.inflight[k]; ok {
+		c.mu.Unlock()
+		call.wg.Wait()
+		return call.snapshot, call.err
+	}
+	call := &snapshotCall{}
+	call.wg.Add(1)
+	c.inflight[k] = call
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		delete(c.inflight, k)
+		c.mu.Unlock()
+		call.wg.Done()
+	}()
+
+	// the snapshot may have been stored by the call finished since the first lookup
+	if snapshot, ok, err := c.store.Get(key); err == nil && ok {
+		call.snapshot = snapshot
+		return snapshot, nil
+	}
+
+	call.snapshot, call.err = create()
+	if call.err != nil {
+		return nil, call.err
+	}
+
+	if err := c.store.Put(key, call.snapshot); err != nil {
+		call.err = err
+		return nil, err
+	}
+	return call.snapshot, nil
+}
+
+var defaultSnapshotCache = NewSnapshotCache(nil)
+
+// CachedScriptOptions configures Session.CreateScriptCached.
+type CachedScriptOptions struct {
+	// Name of the script, defaults to "frida-go".
+	Name string
+	// EmbedScript is the script (usually the large agent) snapshotted into the heap.
+	EmbedScript string
+	// WarmupScript is optional script run before taking the snapshot.
+	WarmupScript string
+	// Runtime defaults to ScriptRuntimeV8 as only v8 supports snapshots.
+	Runtime ScriptRuntime
+	// Target is added to the snapshot key, see SnapshotKey.Target.
+	Target string
+	// Cache defaults to the process-wide in-memory cache.
+	Cache *SnapshotCache
+}
+
+// CreateScriptCached creates the script from the source on top of the snapshot of opts.EmbedScript
+// taken from the cache; the snapshot is created on the first use.
+func (s *Session) CreateScriptCached(source string, opts *CachedScriptOptions) (*Script, error) {
+	if opts == nil || opts.EmbedScript == "" {
+		return nil, errors.New("you need to provide embed script to snapshot")
+	}
+
+	cache := opts.Cache
+	if cache == nil {
+		cache = defaultSnapshotCache
+	}
+
+	rt := opts.Runtime
+	if rt == ScriptRuntimeDefault {
+		rt = ScriptRuntimeV8
+	}
+
+	key := NewSnapshotKey(opts.EmbedScript, opts.WarmupScript, rt)
+	key.Target = opts.Target
+
+	snapshot, err := cache.Snapshot(s, key, opts.EmbedScript, opts.WarmupScript)
+	if err != nil {
+		return nil, err
+	}
+
+	scriptOpts, err := NewScriptOptionsFromConfig(&ScriptConfig{
+		Name:     opts.Name,
+		Snapshot: snapshot,
+		Runtime:  rt,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer scriptOpts.Clean()
+
+	return s.CreateScriptWithOptions(source, scriptOpts)
+}
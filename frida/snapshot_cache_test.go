@@ -0,0 +1,195 @@
+package frida
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+var testSnapshotKey = SnapshotKey{
+	EmbedHash:  hashScript("embed"),
+	WarmupHash: hashScript("warmup"),
+	Runtime:    ScriptRuntimeV8,
+	Version:    "16.0.0",
+}
+
+func TestSnapshotKeyString(t *testing.T) {
+	base := testSnapshotKey.String()
+	if len(base) != 64 {
+		t.Fatalf("String() = %q, want 64 hex characters", base)
+	}
+	if again := testSnapshotKey.String(); again != base {
+		t.Fatalf("String() is not stable: %q != %q", again, base)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(k *SnapshotKey)
+	}{
+		{name: "embed", modify: func(k *SnapshotKey) { k.EmbedHash = hashScript("other") }},
+		{name: "warmup", modify: func(k *SnapshotKey) { k.WarmupHash = hashScript("") }},
+		{name: "runtime", modify: func(k *SnapshotKey) { k.Runtime = ScriptRuntimeQJS }},
+		{name: "version", modify: func(k *SnapshotKey) { k.Version = "16.0.1" }},
+		{name: "target", modify: func(k *SnapshotKey) { k.Target = "arm64" }},
+	}
+
+	for _, tt := range tests {
+		k := testSnapshotKey
+		tt.modify(&k)
+		if k.String() == base {
+			t.Errorf("changing %s does not change the key", tt.name)
+		}
+	}
+}
+
+func TestDirSnapshotStore(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewDirSnapshotStore(filepath.Join(dir, "snapshots"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok, err := store.Get(testSnapshotKey); err != nil || ok {
+		t.Fatalf("Get() of missing key = %v, %v, want false, nil", ok, err)
+	}
+
+	for _, snapshot := range [][]byte{[]byte("first"), []byte("second")} {
+		if err := store.Put(testSnapshotKey, snapshot); err != nil {
+			t.Fatal(err)
+		}
+		got, ok, err := store.Get(testSnapshotKey)
+		if err != nil || !ok || !bytes.Equal(got, snapshot) {
+			t.Fatalf("Get() = %q, %v, %v, want %q, true, nil", got, ok, err, snapshot)
+		}
+	}
+
+	entries, err := os.ReadDir(filepath.Dir(store.path(testSnapshotKey)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".snapshot-") {
+			t.Errorf("temporary file %s left behind", entry.Name())
+		}
+	}
+}
+
+func TestDirSnapshotStorePrune(t *testing.T) {
+	snapshotName := testSnapshotKey.String() + ".snapshot"
+
+	tests := []struct {
+		dir   string
+		files []string
+		kept  bool
+	}{
+		{dir: "frida-16.0.0", files: []string{snapshotName}, kept: true},
+		{dir: "frida-15.2.2", files: []string{snapshotName, ".snapshot-123"}, kept: false},
+		{dir: "frida-15.1.0", kept: false},
+		{dir: "frida-15.0.0", files: []string{snapshotName, "notes.txt"}, kept: true},
+		{dir: "frida-14.0.0", files: []string{"abc.snapshot"}, kept: true},
+		{dir: "frida-13.0.0", files: []string{"nested/" + snapshotName}, kept: true},
+		{dir: "cache", files: []string{snapshotName}, kept: true},
+	}
+
+	dir := t.TempDir()
+	for _, tt := range tests {
+		if err := os.MkdirAll(filepath.Join(dir, tt.dir), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		for _, name := range tt.files {
+			pth := filepath.Join(dir, tt.dir, name)
+			if err := os.MkdirAll(filepath.Dir(pth), 0o755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(pth, []byte("data"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	store, err := NewDirSnapshotStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Prune("16.0.0"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range tests {
+		_, err := os.Stat(filepath.Join(dir, tt.dir))
+		if kept := err == nil; kept != tt.kept {
+			t.Errorf("Prune() kept %s = %v, want %v", tt.dir, kept, tt.kept)
+		}
+	}
+}
+
+func TestSnapshotCacheSingleflight(t *testing.T) {
+	cache := NewSnapshotCache(nil)
+
+	var calls int32
+	release := make(chan struct{})
+	create := func() ([]byte, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return []byte("snapshot"), nil
+	}
+
+	const n = 8
+	var wg sync.WaitGroup
+	results := make([][]byte, n)
+	errs := make([]error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], errs[i] = cache.snapshot(testSnapshotKey, create)
+		}(i)
+	}
+
+	// wait for the first call to be in flight before letting it finish
+	for atomic.LoadInt32(&calls) == 0 {
+	}
+	close(release)
+	wg.Wait()
+
+	for i := 0; i < n; i++ {
+		if errs[i] != nil || string(results[i]) != "snapshot" {
+			t.Errorf("snapshot() = %q, %v, want \"snapshot\", nil", results[i], errs[i])
+		}
+	}
+	// calls arriving after the first one finished are served from the store
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Errorf("create called %d times, want 1", got)
+	}
+
+	got, err := cache.snapshot(testSnapshotKey, func() ([]byte, error) {
+		t.Error("create called for the stored snapshot")
+		return nil, nil
+	})
+	if err != nil || string(got) != "snapshot" {
+		t.Errorf("snapshot() = %q, %v, want \"snapshot\", nil", got, err)
+	}
+}
+
+func TestSnapshotCacheErrorNotStored(t *testing.T) {
+	cache := NewSnapshotCache(nil)
+	errCreate := errors.New("create failed")
+
+	if _, err := cache.snapshot(testSnapshotKey, func() ([]byte, error) {
+		return nil, errCreate
+	}); !errors.Is(err, errCreate) {
+		t.Fatalf("snapshot() error = %v, want %v", err, errCreate)
+	}
+
+	got, err := cache.snapshot(testSnapshotKey, func() ([]byte, error) {
+		return []byte("retried"), nil
+	})
+	if err != nil || string(got) != "retried" {
+		t.Errorf("snapshot() = %q, %v, want \"retried\", nil", got, err)
+	}
+}
@@ -0,0 +1,366 @@
+package frida
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const base64VLQChars = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/"
+
+var (
+	inlineSourceMapRe = regexp.MustCompile(`//[#@] sourceMappingURL=data:application/json;(?:charset=[^;,]+;)?base64,([A-Za-z0-9+/=]+)`)
+	stackLocationRe   = regexp.MustCompile(`([^\s()]+?):(\d+)(?::(\d+))?`)
+)
+
+// OriginalPosition is the position in the original source, lines and columns are 1-based.
+type OriginalPosition struct {
+	Source string
+	Line   int
+	Column int
+	Name   string
+}
+
+type sourceMapping struct {
+	genColumn int
+	source    int
+	line      int
+	column    int
+	name      int
+}
+
+// SourceMap is the decoded source map (revision 3) used to map positions in
+// the bundled agent back to the original sources.
+type SourceMap struct {
+	File    string
+	Sources []string
+	Names   []string
+	lines   [][]sourceMapping
+}
+
+type rawSourceMap struct {
+	Version    int      `json:"version"`
+	File       string   `json:"file"`
+	SourceRoot string   `json:"sourceRoot"`
+	Sources    []string `json:"sources"`
+	Names      []string `json:"names"`
+	Mappings   string   `json:"mappings"`
+}
+
+// ParseSourceMap decodes the source map JSON.
+func ParseSourceMap(data []byte) (*SourceMap, error) {
+	var raw rawSourceMap
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	if raw.Version != 3 {
+		return nil, fmt.Errorf("unsupported source map version %d", raw.Version)
+	}
+
+	sources := make([]string, len(raw.Sources))
+	for i, src := range raw.Sources {
+		if raw.SourceRoot != "" && !path.IsAbs(src) {
+			src = path.Join(raw.SourceRoot, src)
+		}
+		sources[i] = src
+	}
+
+	lines, err := decodeMappings(raw.Mappings)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SourceMap{
+		File:    raw.File,
+		Sources: sources,
+		Names:   raw.Names,
+		lines:   lines,
+	}, nil
+}
+
+// SourceMapFromInline extracts and decodes the inline source map
+// (sourceMappingURL data comment) from the bundled source.
+func SourceMapFromInline(source string) (*SourceMap, error) {
+	matches := inlineSourceMapRe.FindAllStringSubmatch(source, -1)
+	if len(matches) == 0 {
+		return nil, errors.New("no inline source map found")
+	}
+
+	data, err := base64.StdEncoding.DecodeString(matches[len(matches)-1][1])
+	if err != nil {
+		return nil, err
+	}
+	return ParseSourceMap(data)
+}
+
+// Lookup returns the original position for the 1-based line and column in the generated source.
+func (s *SourceMap) Lookup(line, column int) (OriginalPosition, bool) {
+	if line < 1 || line > len(s.lines) {
+		return OriginalPosition{}, false
+	}
+
+	segments := s.lines[line-1]
+	col := column - 1
+	if col < 0 {
+		col = 0
+	}
+
+	i := sort.Search(len(segments), func(i int) bool {
+		return segments[i].genColumn > col
+	}) - 1
+	if i < 0 {
+		return OriginalPosition{}, false
+	}
+
+	m := segments[i]
+	if m.source < 0 || m.source >= len(s.Sources) {
+		return OriginalPosition{}, false
+	}
+
+	pos := OriginalPosition{
+		Source: s.Sources[m.source],
+		Line:   m.line + 1,
+		Column: m.column + 1,
+	}
+	if m.name >= 0 && m.name < len(s.Names) {
+		pos.Name = s.Names[m.name]
+	}
+	return pos, true
+}
+
+// RewriteStack replaces the file:line[:column] locations of the frames in the generated file
+// with the original positions. The generated file is s.File, generated adds other names the
+// bundle is known by, e.g. the script name; locations in the other files are left untouched.
+func (s *SourceMap) RewriteStack(stack string, generated ...string) string {
+	files := make([]string, 0, len(generated)+1)
+	if s.File != "" {
+		files = append(files, s.File)
+	}
+	files = append(files, generated...)
+
+	return stackLocationRe.ReplaceAllStringFunc(stack, func(loc string) string {
+		parts := stackLocationRe.FindStringSubmatch(loc)
+		if !matchesGeneratedFile(parts[1], files) {
+			return loc
+		}
+
+		line, _ := strconv.Atoi(parts[2])
+		column := 1
+		if parts[3] != "" {
+			column, _ = strconv.Atoi(parts[3])
+		}
+
+		pos, ok := s.Lookup(line, column)
+		if !ok {
+			return loc
+		}
+		return fmt.Sprintf("%s:%d:%d", pos.Source, pos.Line, pos.Column)
+	})
+}
+
+// matchesGeneratedFile returns whether the file of the stack frame is one of the files,
+// ignoring the file:// scheme and the leading slash frida adds to the script names.
+func matchesGeneratedFile(file string, files []string) bool {
+	normalize := func(f string) string {
+		return strings.TrimPrefix(strings.TrimPrefix(f, "file://"), "/")
+	}
+
+	file = normalize(file)
+	for _, f := range files {
+		if f != "" && normalize(f) == file {
+			return true
+		}
+	}
+	return false
+}
+
+// scriptFiles returns the file names frida gives to the script with the name.
+func scriptFiles(name string) []string {
+	if name == "" {
+		return nil
+	}
+	return []string{name, name + ".js"}
+}
+
+// RewriteMessage maps the filename, line, column and stack of the error message
+// to the original sources; messages of other types are left untouched.
+// generated is passed to RewriteStack.
+func (s *SourceMap) RewriteMessage(m *Message, generated ...string) {
+	if m.Type != MessageTypeError {
+		return
+	}
+	if pos, ok := s.Lookup(m.LineNumber, m.ColumnNumber); ok {
+		m.Filename = pos.Source
+		m.LineNumber = pos.Line
+		m.ColumnNumber = pos.Column
+	}
+	m.Stack = s.RewriteStack(m.Stack, generated...)
+}
+
+// rewriteRawMessage rewrites the raw message received in the "message" signal from the
+// script with the name. Only the location fields are replaced, the other fields are kept
+// byte for byte in the original order.
+func (s *SourceMap) rewriteRawMessage(message, scriptName string) string {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(message), &raw); err != nil {
+		return message
+	}
+
+	var typ string
+	if err := json.Unmarshal(raw["type"], &typ); err != nil || typ != string(MessageTypeError) {
+		return message
+	}
+
+	replaced := make(map[string]any)
+
+	var line, column int
+	json.Unmarshal(raw["lineNumber"], &line)
+	json.Unmarshal(raw["columnNumber"], &column)
+	if pos, ok := s.Lookup(line, column); ok {
+		replaced["fileName"] = pos.Source
+		replaced["lineNumber"] = pos.Line
+		replaced["columnNumber"] = pos.Column
+	}
+
+	var stack string
+	if err := json.Unmarshal(raw["stack"], &stack); err == nil {
+		if rewritten := s.RewriteStack(stack, scriptFiles(scriptName)...); rewritten != stack {
+			replaced["stack"] = rewritten
+		}
+	}
+
+	if len(replaced) == 0 {
+		return message
+	}
+
+	rewritten, err := replaceJSONFields(message, replaced)
+	if err != nil {
+		return message
+	}
+	return rewritten
+}
+
+// replaceJSONFields replaces the values of the top-level fields of the JSON object keeping the
+// other values as they are and the order of the fields; fields missing in the object are not added.
+func replaceJSONFields(object string, fields map[string]any) (string, error) {
+	dec := json.NewDecoder(strings.NewReader(object))
+	if _, err := dec.Token(); err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+
+	buf.WriteByte('{')
+	for i := 0; dec.More(); i++ {
+		tok, err := dec.Token()
+		if err != nil {
+			return "", err
+		}
+		key, _ := tok.(string)
+
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return "", err
+		}
+
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		if err := enc.Encode(key); err != nil {
+			return "", err
+		}
+		buf.Truncate(buf.Len() - 1) // Encode adds the new line
+		buf.WriteByte(':')
+
+		if v, ok := fields[key]; ok {
+			if err := enc.Encode(v); err != nil {
+				return "", err
+			}
+			buf.Truncate(buf.Len() - 1)
+		} else {
+			buf.Write(value)
+		}
+	}
+	buf.WriteByte('}')
+	return buf.String(), nil
+}
+
+func decodeMappings(mappings string) ([][]sourceMapping, error) {
+	var lines [][]sourceMapping
+	source, line, column, name := 0, 0, 0, 0
+
+	for _, lineMappings := range strings.Split(mappings, ";") {
+		var segments []sourceMapping
+		genColumn := 0
+
+		for _, segment := range strings.Split(lineMappings, ",") {
+			if segment == "" {
+				continue
+			}
+			values, err := decodeVLQ(segment)
+			if err != nil {
+				return nil, err
+			}
+
+			genColumn += values[0]
+			m := sourceMapping{genColumn: genColumn, source: -1, name: -1}
+			if len(values) >= 4 {
+				source += values[1]
+				line += values[2]
+				column += values[3]
+				m.source, m.line, m.column = source, line, column
+			}
+			if len(values) >= 5 {
+				name += values[4]
+				m.name = name
+			}
+			segments = append(segments, m)
+		}
+
+		sort.SliceStable(segments, func(i, j int) bool {
+			return segments[i].genColumn < segments[j].genColumn
+		})
+		lines = append(lines, segments)
+	}
+
+	return lines, nil
+}
+
+func decodeVLQ(segment string) ([]int, error) {
+	var values []int
+	value, shift := 0, 0
+
+	for i := 0; i < len(segment); i++ {
+		digit := strings.IndexByte(base64VLQChars, segment[i])
+		if digit < 0 {
+			return nil, fmt.Errorf("invalid character %q in source map mappings", segment[i])
+		}
+
+		value += (digit & 31) << shift
+		if digit&32 != 0 {
+			shift += 5
+			continue
+		}
+
+		if value&1 != 0 {
+			values = append(values, -(value >> 1))
+		} else {
+			values = append(values, value>>1)
+		}
+		value, shift = 0, 0
+	}
+
+	if shift != 0 {
+		return nil, errors.New("truncated segment in source map mappings")
+	}
+	return values, nil
+}
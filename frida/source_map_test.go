@@ -0,0 +1,154 @@
+package frida
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDecodeVLQ(t *testing.T) {
+	tests := []struct {
+		segment string
+		want    []int
+		wantErr bool
+	}{
+		{segment: "A", want: []int{0}},
+		{segment: "C", want: []int{1}},
+		{segment: "D", want: []int{-1}},
+		{segment: "f", want: []int{-15}},
+		{segment: "gB", want: []int{16}},
+		{segment: "hB", want: []int{-16}},
+		{segment: "2HwH", want: []int{123, 120}},
+		{segment: "AAAA", want: []int{0, 0, 0, 0}},
+		{segment: "AACAC", want: []int{0, 0, 1, 0, 1}},
+		{segment: "g", wantErr: true},
+		{segment: "A!", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := decodeVLQ(tt.segment)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("decodeVLQ(%q) error = %v, wantErr %v", tt.segment, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("decodeVLQ(%q) = %v, want %v", tt.segment, got, tt.want)
+		}
+	}
+}
+
+// testSourceMap maps the generated agent.js to src/a.ts:
+//
+//	line 1: column 3 -> a.ts 1:1, column 11 -> a.ts 2:5 (name "foo")
+//	line 2: no mappings
+//	line 3: column 1 -> a.ts 4:1
+const testSourceMap = `{
+	"version": 3,
+	"file": "agent.js",
+	"sources": ["a.ts"],
+	"sourceRoot": "src",
+	"names": ["foo"],
+	"mappings": "EAAA,QACIA;;AAEJ"
+}`
+
+func TestSourceMapLookup(t *testing.T) {
+	sm, err := ParseSourceMap([]byte(testSourceMap))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		line, column int
+		want         OriginalPosition
+		found        bool
+	}{
+		{line: 1, column: 1, found: false},
+		{line: 1, column: 2, found: false},
+		{line: 1, column: 3, want: OriginalPosition{Source: "src/a.ts", Line: 1, Column: 1}, found: true},
+		{line: 1, column: 10, want: OriginalPosition{Source: "src/a.ts", Line: 1, Column: 1}, found: true},
+		{line: 1, column: 11, want: OriginalPosition{Source: "src/a.ts", Line: 2, Column: 5, Name: "foo"}, found: true},
+		{line: 1, column: 100, want: OriginalPosition{Source: "src/a.ts", Line: 2, Column: 5, Name: "foo"}, found: true},
+		{line: 2, column: 1, found: false},
+		{line: 3, column: 1, want: OriginalPosition{Source: "src/a.ts", Line: 4, Column: 1}, found: true},
+		{line: 0, column: 1, found: false},
+		{line: 4, column: 1, found: false},
+	}
+
+	for _, tt := range tests {
+		got, found := sm.Lookup(tt.line, tt.column)
+		if found != tt.found {
+			t.Errorf("Lookup(%d, %d) found = %v, want %v", tt.line, tt.column, found, tt.found)
+			continue
+		}
+		if found && got != tt.want {
+			t.Errorf("Lookup(%d, %d) = %+v, want %+v", tt.line, tt.column, got, tt.want)
+		}
+	}
+}
+
+func TestSourceMapRewriteStack(t *testing.T) {
+	sm, err := ParseSourceMap([]byte(testSourceMap))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		stack     string
+		generated []string
+		want      string
+	}{
+		{
+			stack: "Error: x\n    at foo (/agent.js:1:11)\n    at frida/runtime/core.js:2:1",
+			want:  "Error: x\n    at foo (src/a.ts:2:5)\n    at frida/runtime/core.js:2:1",
+		},
+		{
+			stack: "at http://example.com:2:3",
+			want:  "at http://example.com:2:3",
+		},
+		{
+			stack:     "at /frida-go.js:3:1",
+			generated: scriptFiles("frida-go"),
+			want:      "at src/a.ts:4:1",
+		},
+		{
+			stack: "at /agent.js:2:1",
+			want:  "at /agent.js:2:1",
+		},
+	}
+
+	for _, tt := range tests {
+		if got := sm.RewriteStack(tt.stack, tt.generated...); got != tt.want {
+			t.Errorf("RewriteStack(%q) = %q, want %q", tt.stack, got, tt.want)
+		}
+	}
+}
+
+func TestSourceMapRewriteRawMessage(t *testing.T) {
+	sm, err := ParseSourceMap([]byte(testSourceMap))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		message string
+		want    string
+	}{
+		{
+			message: `{"type":"error","description":"a < b & c","stack":"at /agent.js:1:11","fileName":"/agent.js","lineNumber":1,"columnNumber":11}`,
+			want:    `{"type":"error","description":"a < b & c","stack":"at src/a.ts:2:5","fileName":"src/a.ts","lineNumber":2,"columnNumber":5}`,
+		},
+		{
+			message: `{"type":"send","payload":"<x>"}`,
+			want:    `{"type":"send","payload":"<x>"}`,
+		},
+		{
+			message: `{"type":"error","stack":"at other.js:1:1","lineNumber":2,"columnNumber":1}`,
+			want:    `{"type":"error","stack":"at other.js:1:1","lineNumber":2,"columnNumber":1}`,
+		},
+	}
+
+	for _, tt := range tests {
+		if got := sm.rewriteRawMessage(tt.message, "frida-go"); got != tt.want {
+			t.Errorf("rewriteRawMessage(%s) = %s, want %s", tt.message, got, tt.want)
+		}
+	}
+}
@@ -159,17 +159,33 @@ const (
 	SessionDetachReasonApplicationRequested SessionDetachReason = iota + 1
 	SessionDetachReasonProcessReplaced
 	SessionDetachReasonProcessTerminated
-	SessionDetachReasonServerTerminated
+	SessionDetachReasonConnectionTerminated
 	SessionDetachReasonDeviceLost
 )
 
+// SessionDetachReasonServerTerminated is the old name of SessionDetachReasonConnectionTerminated.
+//
+// Deprecated: use SessionDetachReasonConnectionTerminated instead.
+const SessionDetachReasonServerTerminated = SessionDetachReasonConnectionTerminated
+
 func (reason SessionDetachReason) String() string {
-	return [...]string{"",
+	reasons := [...]string{"",
 		"application-requested",
 		"process-replaced",
 		"process-terminated",
-		"server-terminated",
-		"device-list"}[reason]
+		"connection-terminated",
+		"device-lost"}
+	if reason < 0 || int(reason) >= len(reasons) {
+		return fmt.Sprintf("unknown(%d)", int(reason))
+	}
+	return reasons[reason]
+}
+
+// IsRecoverable returns whether the session detached with the reason can be
+// resumed with Session.Resume, which is only the case for persistent sessions
+// whose connection got terminated.
+func (reason SessionDetachReason) IsRecoverable() bool {
+	return reason == SessionDetachReasonConnectionTerminated
 }
 
 type SnapshotTransport int
@@ -216,6 +232,22 @@ func (p PackageInstallPhase) String() string {
 		"complete"}[p]
 }
 
+type PackageRole int
+
+const (
+	PackageRoleRuntime PackageRole = iota
+	PackageRoleDevelopment
+	PackageRoleOptional
+	PackageRolePeer
+)
+
+func (p PackageRole) String() string {
+	return [...]string{"runtime",
+		"development",
+		"optional",
+		"peer"}[p]
+}
+
 type JsPlatform int
 
 const (
@@ -78,6 +78,8 @@ const (
 	gFileMonitorEvent        gTypeName = "GFileMonitorEvent"
 	gSocketAddress           gTypeName = "GSocketAddress"
 	gVariant                 gTypeName = "GVariant"
+	gdouble                  gTypeName = "gdouble"
+	fridaPackageInstallPhase gTypeName = "FridaPackageInstallPhase"
 )
 
 type unmarshallerFunc func(val *C.GValue) any
@@ -96,6 +98,8 @@ var gTypeString = map[gTypeName]unmarshallerFunc{
 	gFileMonitorEvent:        getFm,
 	gSocketAddress:           getGSocketAddress,
 	gVariant:                 getGVariant,
+	gdouble:                  getDouble,
+	fridaPackageInstallPhase: getFridaPackageInstallPhase,
 }
 
 // GValueToGo is the function that is called upon unmarshalling glib values
@@ -185,6 +189,16 @@ func getInt(val *C.GValue) any {
 	return int(v)
 }
 
+func getDouble(val *C.GValue) any {
+	v := C.g_value_get_double(val)
+	return float64(v)
+}
+
+func getFridaPackageInstallPhase(val *C.GValue) any {
+	phase := C.g_value_get_enum(val)
+	return PackageInstallPhase(int(phase))
+}
+
 func getFm(val *C.GValue) any {
 	v := C.int(C.g_value_get_int(val))
 
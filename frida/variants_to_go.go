@@ -48,6 +48,16 @@ static void iter_double_array_of_dicts(GVariant *var, char *data)
 	}
 }
 
+static void loop_children(GVariant *var, char *slc)
+{
+	GVariantIter iter;
+	GVariant *child;
+
+	g_variant_iter_init(&iter, var);
+	while ((child = g_variant_iter_next_value(&iter)) != NULL)
+		populateSliceLoop(child, slc);
+}
+
 static char* read_byte_array(GVariant *variant, int * n_elements)
 {
 	guint8 * array = NULL;
@@ -104,6 +114,14 @@ func gVariantToGo(variant *C.GVariant) any {
 		return boolFromVariant(variant)
 	case "x":
 		return int64FromVariant(variant)
+	case "i":
+		return int(C.g_variant_get_int32(variant))
+	case "u":
+		return uint(C.g_variant_get_uint32(variant))
+	case "t":
+		return uint64(C.g_variant_get_uint64(variant))
+	case "d":
+		return float64(C.g_variant_get_double(variant))
 	case "v":
 		v := C.g_variant_get_variant(variant)
 		return gVariantToGo(v)
@@ -197,3 +215,16 @@ func gHashTableToMap(ht *C.GHashTable) map[string]any {
 
 	return data
 }
+
+// gVariantChildrenToGo converts every child of the container variant, unlike gVariantToGo
+// it keeps the dictionaries of "aa{sv}" separate.
+func gVariantChildrenToGo(variant *C.GVariant) []any {
+	s := variantSlice{}
+	C.loop_children(variant, (*C.char)(unsafe.Pointer(&s)))
+	arr := make([]any, len(s.s))
+	for i, elem := range s.s {
+		arr[i] = gVariantToGo(elem)
+		C.g_variant_unref(elem)
+	}
+	return arr
+}
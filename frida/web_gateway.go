@@ -0,0 +1,166 @@
+package frida
+
+//#include <frida-core.h>
+import "C"
+import (
+	"context"
+	"unsafe"
+)
+
+// WebGatewayEventType represents the type of the WebGatewayEvent.
+type WebGatewayEventType int
+
+const (
+	WebGatewayStarted WebGatewayEventType = iota
+	WebGatewayStopped
+	WebGatewayError
+)
+
+func (w WebGatewayEventType) String() string {
+	return [...]string{"started",
+		"stopped",
+		"error"}[w]
+}
+
+// WebGatewayEvent is sent by WebGateway.Events when the gateway changes the state.
+type WebGatewayEvent struct {
+	Type WebGatewayEventType
+	Err  error // populated for WebGatewayError
+}
+
+// WebGateway serves the static assets from the asset root of the gateway params and
+// proxies the JSON-RPC of the browser based clients to the target, usually the control
+// endpoint of the Portal.
+type WebGateway struct {
+	gw *C.FridaWebGatewayService
+
+	state  serviceState
+	events eventHub[WebGatewayEvent]
+}
+
+// NewWebGateway creates new web gateway listening on gatewayParams and connecting to targetParams.
+// origin is the origin allowed by CORS, it can be empty to only allow the same origin.
+//
+// Example:
+//
+//	gatewayParams, _ := frida.NewEndpointParameters(&frida.EParams{
+//		Address:   "127.0.0.1",
+//		Port:      8080,
+//		AssetRoot: "./dashboard/dist",
+//	})
+//	targetParams, _ := frida.NewEndpointParameters(&frida.EParams{
+//		Address: "127.0.0.1",
+//		Port:    27042,
+//	})
+//	gw := frida.NewWebGateway(gatewayParams, targetParams, "")
+//	if err := gw.Start(); err != nil {
+//		panic(err)
+//	}
+//	defer gw.Clean()
+func NewWebGateway(gatewayParams, targetParams *EndpointParameters, origin string) *WebGateway {
+	var originC *C.char = nil
+	if origin != "" {
+		originC = C.CString(origin)
+		defer C.free(unsafe.Pointer(originC))
+	}
+
+	gw := C.frida_web_gateway_service_new(gatewayParams.params, targetParams.params, originC)
+	return &WebGateway{
+		gw:    gw,
+		state: serviceState{name: "web gateway"},
+	}
+}
+
+// GatewayParams returns the parameters the gateway listens on.
+// The returned parameters hold their own reference, Clean them once done.
+func (w *WebGateway) GatewayParams() *EndpointParameters {
+	params := C.frida_web_gateway_service_get_gateway_params(w.gw)
+	C.g_object_ref(C.gpointer(params))
+	return &EndpointParameters{params: params}
+}
+
+// TargetParams returns the parameters of the target the gateway connects to.
+// The returned parameters hold their own reference, Clean them once done.
+func (w *WebGateway) TargetParams() *EndpointParameters {
+	params := C.frida_web_gateway_service_get_target_params(w.gw)
+	C.g_object_ref(C.gpointer(params))
+	return &EndpointParameters{params: params}
+}
+
+// Origin returns the origin allowed by CORS.
+func (w *WebGateway) Origin() string {
+	return C.GoString(C.frida_web_gateway_service_get_origin(w.gw))
+}
+
+// IsRunning returns whether the gateway is started.
+func (w *WebGateway) IsRunning() bool {
+	return w.state.isRunning()
+}
+
+// StartWithContext runs Start but with context.
+// This function will properly handle cancelling the frida operation.
+func (w *WebGateway) StartWithContext(ctx context.Context) error {
+	_, err := handleWithContext(ctx, func(c *Cancellable, doneC chan any, errC chan error) {
+		if err := w.Start(WithCancel(c)); err != nil {
+			errC <- err
+			return
+		}
+		doneC <- nil
+	})
+	return err
+}
+
+// Start starts serving the clients.
+func (w *WebGateway) Start(opts ...OptFunc) error {
+	o := setupOptions(opts)
+	return w.state.start(func() error {
+		var err *C.GError
+		C.frida_web_gateway_service_start_sync(w.gw, o.cancellable, &err)
+		return handleGError(err)
+	}, w.emit(WebGatewayStarted))
+}
+
+// StopWithContext runs Stop but with context.
+// This function will properly handle cancelling the frida operation.
+func (w *WebGateway) StopWithContext(ctx context.Context) error {
+	_, err := handleWithContext(ctx, func(c *Cancellable, doneC chan any, errC chan error) {
+		if err := w.Stop(WithCancel(c)); err != nil {
+			errC <- err
+			return
+		}
+		doneC <- nil
+	})
+	return err
+}
+
+// Stop stops the gateway disconnecting all the clients.
+func (w *WebGateway) Stop(opts ...OptFunc) error {
+	o := setupOptions(opts)
+	return w.state.stop(func() error {
+		var err *C.GError
+		C.frida_web_gateway_service_stop_sync(w.gw, o.cancellable, &err)
+		return handleGError(err)
+	}, w.emit(WebGatewayStopped))
+}
+
+// emit returns the func emitting typ, or the error event if the operation failed.
+func (w *WebGateway) emit(typ WebGatewayEventType) func(err error) {
+	return func(err error) {
+		if err != nil {
+			w.events.emit(WebGatewayEvent{Type: WebGatewayError, Err: err})
+			return
+		}
+		w.events.emit(WebGatewayEvent{Type: typ})
+	}
+}
+
+// Events returns the channel receiving the gateway events until ctx is done.
+func (w *WebGateway) Events(ctx context.Context) <-chan WebGatewayEvent {
+	return w.events.subscribe(ctx)
+}
+
+// Clean will clean the resources held by the web gateway.
+func (w *WebGateway) Clean() {
+	w.events.close()
+	clean(unsafe.Pointer(w.gw), unrefFrida)
+}